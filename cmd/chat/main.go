@@ -12,23 +12,26 @@ import (
 	"sider2api/internal/config"
 	"sider2api/internal/converter"
 	appLog "sider2api/internal/log"
+	"sider2api/internal/models"
 	"sider2api/internal/session"
 	"sider2api/internal/siderclient"
 	"sider2api/pkg/types"
 )
 
 const (
-	promptColor  = "\u001b[36m"
-	green        = "\u001b[32m"
-	yellow       = "\u001b[33m"
-	magenta      = "\u001b[35m"
-	red          = "\u001b[31m"
-	gray         = "\u001b[90m"
-	resetColor   = "\u001b[0m"
-	cyan         = "\u001b[36m"
-	defaultModel = "claude-haiku-4.5"
+	promptColor = "\u001b[36m"
+	green       = "\u001b[32m"
+	yellow      = "\u001b[33m"
+	magenta     = "\u001b[35m"
+	red         = "\u001b[31m"
+	gray        = "\u001b[90m"
+	resetColor  = "\u001b[0m"
+	cyan        = "\u001b[36m"
 )
 
+// modelRegistry is the built-in model list until main loads MODELS_FILE.
+var modelRegistry = models.Builtin()
+
 // Simple CLI chat that talks to Sider directly using the Go converters/client.
 func main() {
 	cfg, err := config.Parse(os.Args[1:])
@@ -46,12 +49,22 @@ func main() {
 		cfg.Port = 4141
 	}
 
-	_ = appLog.New(cfg.LogLevel)
+	// logs go to stderr so they do not interleave with streamed replies on stdout
+	logger := appLog.NewWriter(cfg.LogLevel, os.Stderr)
 
 	sessions := session.NewSiderSessionManager(cfg.SiderSessionMaxAge, cfg.ContinuousCID)
-	client := siderclient.New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sessions)
+	sessions.SetLimits(cfg.MaxSessions, cfg.MaxSessionsPerOwner)
+	client, err := siderclient.NewFromConfig(cfg, sessions, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	if modelRegistry, err = models.Load(cfg.ModelsFile); err != nil {
+		fmt.Fprintf(os.Stderr, "models error: %v\n", err)
+		os.Exit(1)
+	}
 
-	model := defaultModel
+	model := modelRegistry.Default().ID
 
 	fmt.Println("Sider2API CLI chat. Commands: " +
 		colorize("/model <name>", magenta) + ", " +
@@ -95,6 +108,7 @@ func main() {
 			ConversationID:  conversationID,
 			ParentMessageID: parentMessageID,
 			ContinuousCID:   cfg.ContinuousCID,
+			Models:          modelRegistry,
 		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%sconvert error:%s %v\n", red, resetColor, err)
@@ -280,23 +294,12 @@ func handleCommand(cmd string, model *string, thinkEnabled, searchEnabled *bool,
 }
 
 func availableModels() []string {
-	return []string{
-		"gemini-2.5-flash",
-		"claude-haiku-4.5",
-		"gpt-5-mini",
-		"gpt-5.1",
-		"claude-4.5-sonnet",
-		"gemini-3.0-pro",
-	}
+	return modelRegistry.IDs()
 }
 
 func isAllowedModel(name string) bool {
-	for _, m := range availableModels() {
-		if m == name {
-			return true
-		}
-	}
-	return false
+	_, ok := modelRegistry.Lookup(name)
+	return ok
 }
 
 func spinner(prefix string, done <-chan struct{}) {
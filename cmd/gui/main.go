@@ -55,6 +55,7 @@ func main() {
     statusLabel := widget.NewLabel("Idle")
 
     var httpSrv *http.Server
+    var srv *server.Server
 
     startBtn := widget.NewButton("Start Server", func() {
         if httpSrv != nil {
@@ -76,7 +77,11 @@ func main() {
         cfg.UseEnvToken = useEnv.Checked
         cfg.EnableUI = enableUI.Checked
 
-        srv := server.New(cfg, logger)
+        srv, err = server.New(cfg, logger)
+        if err != nil {
+            statusLabel.SetText("Server error: " + err.Error())
+            return
+        }
 
         httpSrv = &http.Server{Addr: fmt.Sprintf("%s:%d", cfg.Host, cfg.Port), Handler: srv.Engine}
 
@@ -101,6 +106,7 @@ func main() {
         } else {
             statusLabel.SetText("Stopped")
         }
+        srv.Close()
         httpSrv = nil
     })
 
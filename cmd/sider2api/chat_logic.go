@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 
@@ -19,36 +21,47 @@ import (
 )
 
 const (
-	chatPromptColor  = "\u001b[36m"
-	chatGreen        = "\u001b[32m"
-	chatYellow       = "\u001b[33m"
-	chatMagenta      = "\u001b[35m"
-	chatRed          = "\u001b[31m"
-	chatGray         = "\u001b[90m"
-	chatResetColor   = "\u001b[0m"
-	chatCyan         = "\u001b[36m"
-	chatDefaultModel = "claude-haiku-4.5"
+	chatPromptColor = "\u001b[36m"
+	chatGreen       = "\u001b[32m"
+	chatYellow      = "\u001b[33m"
+	chatMagenta     = "\u001b[35m"
+	chatRed         = "\u001b[31m"
+	chatGray        = "\u001b[90m"
+	chatResetColor  = "\u001b[0m"
+	chatCyan        = "\u001b[36m"
 )
 
 func chatCmd() *cobra.Command {
-	return &cobra.Command{
+	var maxSessions int
+
+	cmd := &cobra.Command{
 		Use:   "chat",
 		Short: "Interactive chat with AI models",
 		Long:  `Start an interactive chat session with AI models. Supports syntax highlighting and command completion.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runChat()
+			return runChat(maxSessions)
 		},
 	}
+
+	cmd.Flags().IntVar(&maxSessions, "max-sessions", 0, "Cap tracked sessions (overrides MAX_SESSIONS)")
+
+	return cmd
 }
 
 // Simple CLI chat that talks to Sider directly using the Go converters/client.
-func runChat() error {
+func runChat(maxSessions int) error {
 	cfg, err := config.Parse([]string{})
 	if err != nil {
 		return fmt.Errorf("config error: %w", err)
 	}
-	if cfg.SiderAPIToken == "" {
-		return fmt.Errorf("SIDER_API_TOKEN is required (set in .env or env)")
+	if maxSessions > 0 {
+		cfg.MaxSessions = maxSessions
+	}
+	if cfg.SiderAPIToken == "" && !cfg.HasTokenPool() {
+		return fmt.Errorf("SIDER_API_TOKEN or SIDER_TOKENS is required (set in .env or env)")
+	}
+	if err := loadModels(cfg); err != nil {
+		return err
 	}
 
 	// set reasonable defaults for chat
@@ -56,12 +69,22 @@ func runChat() error {
 		cfg.Port = 4141
 	}
 
-	_ = appLog.New(cfg.LogLevel)
+	// logs go to stderr so they do not interleave with streamed replies on stdout
+	logger := appLog.NewWriter(cfg.LogLevel, os.Stderr)
 
 	sessions := session.NewSiderSessionManager(cfg.SiderSessionMaxAge, cfg.ContinuousCID)
-	client := siderclient.New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sessions)
+	sessions.SetLimits(cfg.MaxSessions, cfg.MaxSessionsPerOwner)
+	client, err := siderclient.NewFromConfig(cfg, sessions, logger)
+	if err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
 
-	model := chatDefaultModel
+	// with a pool configured the client picks the token; the env token is part of the pool
+	if cfg.HasTokenPool() {
+		cfg.SiderAPIToken = ""
+	}
+
+	model := modelRegistry.Default().ID
 
 	fmt.Println("Sider2API CLI chat. Commands: " +
 		colorize("/model <name>", chatMagenta) + ", " +
@@ -105,14 +128,21 @@ func runChat() error {
 			ConversationID:  conversationID,
 			ParentMessageID: parentMessageID,
 			ContinuousCID:   cfg.ContinuousCID,
+			Models:          modelRegistry,
 		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%sconvert error:%s %v\n", chatRed, chatResetColor, err)
 			return
 		}
+		for _, w := range siderReq.Warnings {
+			printLine("Warning", w, chatYellow)
+		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.ChatTimeout)
 		defer cancel()
+		// Ctrl+C while a reply streams stops the reply, not the program
+		stopInterrupts := cancelOnInterrupt(cancel)
+		defer stopInterrupts()
 
 		// Stream output
 		var thinkStarted bool
@@ -185,6 +215,15 @@ func runChat() error {
 			}
 		})
 
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			// the turn is dropped; the next message threads onto the last full reply
+			fmt.Printf("\n%s(reply canceled)%s\n", chatGray, chatResetColor)
+			if conversationID == "" {
+				conversationID = resp.ConversationID
+			}
+			history = history[:len(history)-1]
+			return
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "\n%schat error:%s %v\n", chatRed, chatResetColor, err)
 			return
@@ -290,6 +329,24 @@ func runChat() error {
 	}
 }
 
+// cancelOnInterrupt calls cancel on SIGINT until the returned stop function is called.
+func cancelOnInterrupt(cancel context.CancelFunc) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
 func promptForModel(model string) string {
 	return fmt.Sprintf("%s[%s]%s > ", chatPromptColor, model, chatResetColor)
 }
@@ -318,8 +375,8 @@ func handleCommand(cmd string, model *string, thinkEnabled, searchEnabled *bool,
 			fmt.Println(colorize("Usage: /model <name>", chatRed))
 			return true
 		}
-		name := strings.TrimSpace(parts[1])
-		if !isAllowedModel(name) {
+		name, ok := resolveModel(parts[1])
+		if !ok {
 			fmt.Println(colorize("Unknown model. Use /models to list.", chatRed))
 			return true
 		}
@@ -347,26 +404,6 @@ func handleCommand(cmd string, model *string, thinkEnabled, searchEnabled *bool,
 	return false
 }
 
-func availableModels() []string {
-	return []string{
-		"gemini-2.5-flash",
-		"claude-haiku-4.5",
-		"gpt-5-mini",
-		"gpt-5.1",
-		"claude-4.5-sonnet",
-		"gemini-3.0-pro",
-	}
-}
-
-func isAllowedModel(name string) bool {
-	for _, m := range availableModels() {
-		if m == name {
-			return true
-		}
-	}
-	return false
-}
-
 func spinner(prefix string, done <-chan struct{}) {
 	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	i := 0
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sider2api/internal/apikeys"
+	"sider2api/internal/config"
+)
+
+func keysCmd() *cobra.Command {
+	var keysFile string
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage proxy API keys",
+		Long:  `Create, list and revoke the API keys clients use to call this proxy. Secrets are stored hashed.`,
+	}
+	cmd.PersistentFlags().StringVar(&keysFile, "file", "", "keys file (defaults to API_KEYS_FILE)")
+
+	openStore := func() (*apikeys.Store, error) {
+		if keysFile == "" {
+			cfg, err := config.Parse([]string{})
+			if err != nil {
+				return nil, fmt.Errorf("config error: %w", err)
+			}
+			keysFile = cfg.APIKeysFile
+		}
+		return apikeys.Open(keysFile)
+	}
+
+	var (
+		opts   apikeys.CreateOptions
+		models string
+	)
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a key and print its secret once",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			opts.Models = nil
+			for _, m := range strings.Split(models, ",") {
+				if m = strings.TrimSpace(m); m != "" {
+					opts.Models = append(opts.Models, m)
+				}
+			}
+			secret, key, err := store.Create(opts)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created key %s (%s)\n", key.ID, store.Path())
+			fmt.Println(secret)
+			fmt.Fprintln(os.Stderr, "Store this secret now; it cannot be shown again.")
+			return nil
+		},
+	}
+	create.Flags().StringVar(&opts.Name, "name", "", "label for the key")
+	create.Flags().StringVar(&opts.SiderToken, "sider-token", "", "Sider token requests with this key use")
+	create.Flags().BoolVar(&opts.UsePool, "pool", false, "use the Sider token pool (or SIDER_API_TOKEN when no pool is set)")
+	create.Flags().StringVar(&models, "models", "", "comma-separated allowed models; a trailing * matches a prefix (default all)")
+	create.Flags().StringVar(&opts.Profile, "profile", "", "upstream header profile for the key's Sider token")
+	create.Flags().DurationVar(&opts.TTL, "expires", 0, "lifetime, e.g. 720h (default never)")
+	create.Flags().IntVar(&opts.RPM, "rpm", 0, "requests per minute (default RATE_LIMIT_RPM)")
+	create.Flags().IntVar(&opts.TPM, "tpm", 0, "tokens per minute (default RATE_LIMIT_TPM)")
+	create.Flags().IntVar(&opts.Concurrency, "concurrency", 0, "concurrent streams (default RATE_LIMIT_CONCURRENCY)")
+	create.Flags().IntVar(&opts.DailyTokens, "daily-tokens", 0, "token quota per UTC day (default QUOTA_DAILY_TOKENS)")
+	create.Flags().IntVar(&opts.MonthlyTokens, "monthly-tokens", 0, "token quota per month (default QUOTA_MONTHLY_TOKENS)")
+	create.Flags().IntVar(&opts.DailyRequests, "daily-requests", 0, "request quota per UTC day (default QUOTA_DAILY_REQUESTS)")
+	create.Flags().IntVar(&opts.MonthlyRequests, "monthly-requests", 0, "request quota per month (default QUOTA_MONTHLY_REQUESTS)")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			keys, err := store.List()
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tKEY\tSTATUS\tTARGET\tMODELS\tEXPIRES")
+			for _, k := range keys {
+				target := "pool"
+				if k.SiderToken != "" {
+					target = "token"
+				}
+				allowed := "*"
+				if len(k.Models) > 0 {
+					allowed = strings.Join(k.Models, ",")
+				}
+				expiry := "never"
+				if k.ExpiresAt != nil {
+					expiry = k.ExpiresAt.Local().Format(time.DateTime)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Hint, k.Status(now), target, allowed, expiry)
+			}
+			return w.Flush()
+		},
+	}
+
+	revoke := &cobra.Command{
+		Use:   "revoke <id>",
+		Short: "Revoke a key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openStore()
+			if err != nil {
+				return err
+			}
+			key, err := store.Revoke(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Revoked key %s\n", key.ID)
+			return nil
+		},
+	}
+
+	cmd.AddCommand(create, list, revoke)
+	return cmd
+}
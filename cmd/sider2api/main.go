@@ -20,6 +20,10 @@ func main() {
 	rootCmd.AddCommand(serveCmd())
 	rootCmd.AddCommand(chatCmd())
 	rootCmd.AddCommand(tuiCmd())
+	rootCmd.AddCommand(keysCmd())
+	rootCmd.AddCommand(usageCmd())
+	rootCmd.AddCommand(mockUpstreamCmd())
+	rootCmd.AddCommand(modelsCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
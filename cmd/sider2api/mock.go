@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"sider2api/internal/config"
+	appLog "sider2api/internal/log"
+	"sider2api/internal/mockupstream"
+)
+
+func mockUpstreamCmd() *cobra.Command {
+	var (
+		host          string
+		port          int
+		scenariosFile string
+		modelList     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mock-upstream",
+		Short: "Run a local mock of the Sider API",
+		Long: `Serve scripted Sider chat, conversation and model list endpoints for offline development and CI.
+Point SIDER_BASE_URL, SIDER_CONVERSATION_URL and SIDER_MODELS_URL at it; any bearer token is accepted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Parse([]string{})
+			if err != nil {
+				return fmt.Errorf("config error: %w", err)
+			}
+			logger := appLog.New(cfg.LogLevel)
+
+			scenarios, err := mockupstream.LoadScenarios(scenariosFile)
+			if err != nil {
+				return fmt.Errorf("scenarios: %w", err)
+			}
+			srv := mockupstream.New(scenarios, logger)
+			if modelList != "" {
+				srv.Models = nil
+				for _, name := range strings.Split(modelList, ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						srv.Models = append(srv.Models, name)
+					}
+				}
+			}
+
+			base := fmt.Sprintf("http://%s:%d", host, port)
+			fmt.Printf("Mock Sider upstream on %s\n", base)
+			fmt.Printf("  SIDER_BASE_URL=%s%s\n", base, mockupstream.ChatPath)
+			fmt.Printf("  SIDER_CONVERSATION_URL=%s%s\n", base, mockupstream.ConversationPath)
+			fmt.Printf("  SIDER_MODELS_URL=%s%s\n", base, mockupstream.ModelsPath)
+			fmt.Println("Scenarios:")
+			for _, s := range scenarios {
+				trigger := s.Match
+				if s.Default {
+					trigger = "(default)"
+				}
+				fmt.Printf("  %-18s %s\n", s.Name, strings.TrimSpace(trigger))
+			}
+
+			return http.ListenAndServe(fmt.Sprintf("%s:%d", host, port), srv.Handler())
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "listen address")
+	cmd.Flags().IntVar(&port, "port", 9999, "listen port")
+	cmd.Flags().StringVar(&scenariosFile, "scenarios", "", "YAML scenarios file (default: built-in scenarios)")
+	cmd.Flags().StringVar(&modelList, "models", "", "comma-separated model list to serve (default: the built-in registry)")
+
+	return cmd
+}
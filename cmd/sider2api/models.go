@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"sider2api/internal/config"
+	appLog "sider2api/internal/log"
+	"sider2api/internal/models"
+	"sider2api/internal/siderclient"
+)
+
+// modelRegistry backs /models and /model in chat and the TUI; loadModels replaces
+// it once the config is read.
+var modelRegistry = models.Builtin()
+
+func loadModels(cfg config.Config) error {
+	r, err := models.Load(cfg.ModelsFile)
+	if err != nil {
+		return fmt.Errorf("models: %w", err)
+	}
+	modelRegistry = r
+	return nil
+}
+
+func availableModels() []string {
+	return modelRegistry.IDs()
+}
+
+// resolveModel returns the registry id for a model id or alias.
+func resolveModel(name string) (string, bool) {
+	m, ok := modelRegistry.Lookup(name)
+	return m.ID, ok
+}
+
+func modelsCmd() *cobra.Command {
+	var (
+		refresh bool
+		url     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "List the models in the registry",
+		Long: `Show the model registry: the built-in models merged with MODELS_FILE.
+With --refresh, also fetch the live list from SIDER_MODELS_URL and mark the models
+that are new upstream or no longer listed there.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Parse([]string{})
+			if err != nil {
+				return fmt.Errorf("config error: %w", err)
+			}
+			if err := loadModels(cfg); err != nil {
+				return err
+			}
+
+			list := modelRegistry.Models()
+			status := map[string]string{}
+			if refresh {
+				if url != "" {
+					cfg.ModelsURL = url
+				}
+				live, err := fetchLiveModels(cmd.Context(), cfg)
+				if err != nil {
+					return err
+				}
+				added, retired := models.Diff(modelRegistry, live)
+				for _, m := range added {
+					status[m.ID] = "new"
+				}
+				for _, m := range retired {
+					status[m.ID] = "retired"
+				}
+				list = modelRegistry.Merge(live).Models()
+				defer fmt.Printf("\n%d models upstream: %d new, %d retired\n", len(live), len(added), len(retired))
+			}
+
+			def := modelRegistry.Default().ID
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			header := "ID\tNAME\tUPSTREAM\tCONTEXT\tCAPABILITIES\tALIASES"
+			if refresh {
+				header += "\tSTATUS"
+			}
+			fmt.Fprintln(w, header)
+			for _, m := range list {
+				id := m.ID
+				if id == def {
+					id += " *"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s", id, m.DisplayName, m.UpstreamName(), m.ContextWindow, capabilityList(m), strings.Join(m.Aliases, ","))
+				if refresh {
+					fmt.Fprintf(w, "\t%s", status[m.ID])
+				}
+				fmt.Fprintln(w)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "fetch the live model list and show new and retired models")
+	cmd.Flags().StringVar(&url, "url", "", "model list endpoint (default: SIDER_MODELS_URL)")
+
+	return cmd
+}
+
+// fetchLiveModels reads the model list from cfg.ModelsURL with the configured token.
+func fetchLiveModels(ctx context.Context, cfg config.Config) ([]models.Model, error) {
+	if cfg.ModelsURL == "" {
+		return nil, fmt.Errorf("no model list URL: set SIDER_MODELS_URL or pass --url")
+	}
+	if cfg.SiderAPIToken == "" && !cfg.HasTokenPool() {
+		return nil, fmt.Errorf("SIDER_API_TOKEN or SIDER_TOKENS is required (set in .env or env)")
+	}
+	client, err := siderclient.NewFromConfig(cfg, nil, appLog.NewWriter(cfg.LogLevel, os.Stderr))
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	token := cfg.SiderAPIToken
+	if cfg.HasTokenPool() {
+		token = ""
+	}
+	live, err := client.FetchModels(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("fetch models: %w", err)
+	}
+	return live, nil
+}
+
+func capabilityList(m models.Model) string {
+	var caps []string
+	for _, c := range []struct {
+		name string
+		on   bool
+	}{{"think", m.SupportsThink}, {"search", m.SupportsSearch}, {"vision", m.SupportsVision}, {"images", m.SupportsImages}} {
+		if c.on {
+			caps = append(caps, c.name)
+		}
+	}
+	if len(caps) == 0 {
+		return "-"
+	}
+	return strings.Join(caps, ",")
+}
@@ -46,7 +46,10 @@ func serveCmd() *cobra.Command {
 }
 
 func runHeadlessServer(cfg config.Config, logger *slog.Logger) error {
-	srv := server.New(cfg, logger)
+	srv, err := server.New(cfg, logger)
+	if err != nil {
+		return err
+	}
 
 	// background cleanup for sessions
 	go func() {
@@ -66,6 +69,9 @@ func runHeadlessServer(cfg config.Config, logger *slog.Logger) error {
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		s := <-sigCh
 		logger.Info("received signal, exiting", "signal", s.String())
+		if err := srv.Close(); err != nil {
+			logger.Error("close server resources", "error", err)
+		}
 		os.Exit(0)
 	}()
 
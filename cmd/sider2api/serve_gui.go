@@ -59,7 +59,12 @@ func runWithGUI(cfg config.Config, logger *slog.Logger) error {
 			return
 		}
 
-		srv = server.New(cfg, logger)
+		var err error
+		srv, err = server.New(cfg, logger)
+		if err != nil {
+			statusLabel.SetText("✗ Server error: " + err.Error())
+			return
+		}
 		httpSrv = &http.Server{
 			Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 			Handler: srv.Engine,
@@ -86,6 +91,9 @@ func runWithGUI(cfg config.Config, logger *slog.Logger) error {
 		} else {
 			statusLabel.SetText("Server stopped")
 		}
+		if srv != nil {
+			srv.Close()
+		}
 		httpSrv = nil
 	}
 
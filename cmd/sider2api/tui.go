@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -30,33 +32,52 @@ var (
 	tuiPromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("36")).Bold(true)
 )
 
-const tuiDefaultModelName = "claude-4.5-sonnet"
-
 func tuiCmd() *cobra.Command {
-	return &cobra.Command{
+	var maxSessions int
+
+	cmd := &cobra.Command{
 		Use:   "tui",
 		Short: "Terminal UI chat interface",
 		Long:  `Start a terminal UI chat interface with a more visual experience.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runTUI()
+			return runTUI(maxSessions)
 		},
 	}
+
+	cmd.Flags().IntVar(&maxSessions, "max-sessions", 0, "Cap tracked sessions (overrides MAX_SESSIONS)")
+
+	return cmd
 }
 
-func runTUI() error {
+func runTUI(maxSessions int) error {
 	cfg, err := config.Parse([]string{})
 	if err != nil {
 		return fmt.Errorf("config error: %w", err)
 	}
-	if cfg.SiderAPIToken == "" {
-		return fmt.Errorf("SIDER_API_TOKEN is required (set in .env or env)")
+	if maxSessions > 0 {
+		cfg.MaxSessions = maxSessions
+	}
+	if cfg.SiderAPIToken == "" && !cfg.HasTokenPool() {
+		return fmt.Errorf("SIDER_API_TOKEN or SIDER_TOKENS is required (set in .env or env)")
+	}
+	if err := loadModels(cfg); err != nil {
+		return err
 	}
 
-	logger := appLog.New(cfg.LogLevel)
-	_ = logger
+	// the alternate screen owns the terminal, so logs are discarded
+	logger := appLog.NewWriter(cfg.LogLevel, io.Discard)
 
 	sessions := session.NewSiderSessionManager(cfg.SiderSessionMaxAge, cfg.ContinuousCID)
-	client := siderclient.New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sessions)
+	sessions.SetLimits(cfg.MaxSessions, cfg.MaxSessionsPerOwner)
+	client, err := siderclient.NewFromConfig(cfg, sessions, logger)
+	if err != nil {
+		return fmt.Errorf("config error: %w", err)
+	}
+
+	// with a pool configured the client picks the token; the env token is part of the pool
+	if cfg.HasTokenPool() {
+		cfg.SiderAPIToken = ""
+	}
 
 	p := tea.NewProgram(initialTUIModel(cfg, client, sessions), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -68,13 +89,19 @@ func runTUI() error {
 
 // tea messages
 type chatResultMsg struct {
-	resp types.SiderParsedResponse
+	resp     types.SiderParsedResponse
+	warnings []string
 }
 
 type chatErrorMsg struct {
 	err error
 }
 
+// chatCanceledMsg carries whatever Sider produced before the user canceled the reply.
+type chatCanceledMsg struct {
+	resp types.SiderParsedResponse
+}
+
 type statusMsg string
 
 type tuiModel struct {
@@ -93,6 +120,7 @@ type tuiModel struct {
 	parentMessageID string
 	history         []types.AnthropicMessage
 	sending         bool
+	cancel          context.CancelFunc
 	statusLine      string
 }
 
@@ -110,7 +138,7 @@ func initialTUIModel(cfg config.Config, client *siderclient.Client, sessions *se
 		sessions:   sessions,
 		input:      ti,
 		viewport:   vp,
-		modelName:  tuiDefaultModelName,
+		modelName:  modelRegistry.Default().ID,
 		think:      true,
 		search:     false,
 		messages:   []string{"Welcome to Sider2API TUI. Use /model <name>, /models, /think on|off, /search on|off, /reset, /exit."},
@@ -125,7 +153,15 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "esc":
+		case "ctrl+c":
+			// the first Ctrl+C stops a reply in flight; otherwise it quits
+			if m.sending && m.cancel != nil {
+				m.cancel()
+				m.statusLine = "Canceling..."
+				return m, nil
+			}
+			return m, tea.Quit
+		case "esc":
 			return m, tea.Quit
 		case "enter":
 			line := strings.TrimSpace(m.input.Value())
@@ -152,11 +188,22 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.syncViewport()
 	case chatResultMsg:
 		m.sending = false
+		m.cancel = nil
 		m.statusLine = "Received response"
+		for _, w := range msg.warnings {
+			m.messages = append(m.messages, tuiStatusStyle.Render("[warning] "+w))
+		}
 		m.renderAI(msg.resp)
 		m.syncViewport()
+	case chatCanceledMsg:
+		m.sending = false
+		m.cancel = nil
+		m.statusLine = "Reply canceled"
+		m.renderCanceled(msg.resp)
+		m.syncViewport()
 	case chatErrorMsg:
 		m.sending = false
+		m.cancel = nil
 		m.statusLine = fmt.Sprintf("Error: %v", msg.err)
 		m.messages = append(m.messages, tuiErrorStyle.Render("[error] "+msg.err.Error()))
 		m.syncViewport()
@@ -173,10 +220,13 @@ func (m *tuiModel) sendUserMessage(line string) (tea.Model, tea.Cmd) {
 	m.messages = append(m.messages, tuiUserStyle.Render("You:")+" "+line)
 	m.history = append(m.history, types.AnthropicMessage{Role: "user", Content: line})
 	m.sending = true
-	m.statusLine = "Sending..."
+	m.statusLine = "Sending... (Ctrl+C cancels)"
 	m.syncViewport()
 
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ChatTimeout)
+	m.cancel = cancel
 	return m, tea.Batch(tea.Cmd(func() tea.Msg {
+		defer cancel()
 		anthropicReq := types.AnthropicRequest{
 			Model:    m.modelName,
 			Messages: m.history,
@@ -186,17 +236,19 @@ func (m *tuiModel) sendUserMessage(line string) (tea.Model, tea.Cmd) {
 			ConversationID:  m.conversationID,
 			ParentMessageID: m.parentMessageID,
 			ContinuousCID:   m.cfg.ContinuousCID,
+			Models:          modelRegistry,
 		})
 		if err != nil {
 			return chatErrorMsg{err}
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ChatTimeout)
-		defer cancel()
 		resp, err := m.client.Chat(ctx, siderReq, m.cfg.SiderAPIToken)
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			return chatCanceledMsg{resp: resp}
+		}
 		if err != nil {
 			return chatErrorMsg{err}
 		}
-		return chatResultMsg{resp: resp}
+		return chatResultMsg{resp: resp, warnings: siderReq.Warnings}
 	}))
 }
 
@@ -216,6 +268,21 @@ func (m *tuiModel) renderAI(resp types.SiderParsedResponse) {
 	m.history = append(m.history, types.AnthropicMessage{Role: "assistant", Content: text})
 }
 
+// renderCanceled shows the partial reply and drops the turn from history, so the next
+// message threads onto the last complete reply as the session manager does.
+func (m *tuiModel) renderCanceled(resp types.SiderParsedResponse) {
+	if m.conversationID == "" {
+		m.conversationID = resp.ConversationID
+	}
+	if text := strings.TrimSpace(strings.Join(resp.TextParts, "")); text != "" {
+		m.messages = append(m.messages, tuiAIStyle.Render("AI:")+" "+text)
+	}
+	m.messages = append(m.messages, tuiErrorStyle.Render("[canceled]"))
+	if n := len(m.history); n > 0 {
+		m.history = m.history[:n-1]
+	}
+}
+
 func (m *tuiModel) handleCommand(cmd string) (bool, string) {
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
@@ -231,15 +298,15 @@ func (m *tuiModel) handleCommand(cmd string) (bool, string) {
 		m.parentMessageID = ""
 		return true, "Reset"
 	case "/models":
-		m.messages = append(m.messages, tuiStatusStyle.Render("Available models: "+strings.Join(tuiAvailableModels(), ", ")))
+		m.messages = append(m.messages, tuiStatusStyle.Render("Available models: "+strings.Join(availableModels(), ", ")))
 		return true, ""
 	case "/model":
 		if len(parts) < 2 {
 			m.messages = append(m.messages, tuiStatusStyle.Render("Usage: /model <name>"))
 			return true, ""
 		}
-		name := parts[1]
-		if !tuiIsAllowedModel(name) {
+		name, ok := resolveModel(parts[1])
+		if !ok {
 			m.messages = append(m.messages, tuiStatusStyle.Render("Unknown model. Use /models."))
 			return true, ""
 		}
@@ -282,24 +349,3 @@ func (m tuiModel) View() string {
 		tuiPromptStyle.Render(m.input.View()),
 	)
 }
-
-// Helpers
-func tuiAvailableModels() []string {
-	return []string{
-		"gemini-2.5-flash",
-		"claude-haiku-4.5",
-		"gpt-5-mini",
-		"gpt-5.1",
-		"claude-4.5-sonnet",
-		"gemini-3.0-pro",
-	}
-}
-
-func tuiIsAllowedModel(name string) bool {
-	for _, m := range tuiAvailableModels() {
-		if m == name {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"sider2api/internal/config"
+	"sider2api/internal/usage"
+)
+
+func usageCmd() *cobra.Command {
+	var (
+		ledgerFile string
+		key        string
+		from       string
+		to         string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report usage by day and model",
+		Long:  `Summarize the usage ledger written by the server, grouped by day and model.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if ledgerFile == "" {
+				cfg, err := config.Parse([]string{})
+				if err != nil {
+					return fmt.Errorf("config error: %w", err)
+				}
+				ledgerFile = cfg.UsageLedgerPath
+			}
+			if ledgerFile == "" {
+				return fmt.Errorf("no usage ledger configured (USAGE_LEDGER_PATH is empty)")
+			}
+
+			filter := usage.Filter{Client: key}
+			if key != "" && !strings.Contains(key, ":") {
+				filter.Client = "key:" + key
+			}
+			var err error
+			if filter.From, err = usage.ParseTime(from); err != nil {
+				return err
+			}
+			if filter.To, err = usage.ParseTime(to); err != nil {
+				return err
+			}
+			if len(to) == len(time.DateOnly) {
+				filter.To = filter.To.AddDate(0, 0, 1)
+			}
+
+			report, err := usage.BuildReport(ledgerFile, filter)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(w, "DAY\tMODEL\tREQUESTS\tERRORS\tINPUT\tOUTPUT\tAVG MS\t")
+			for _, g := range report.Groups {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t\n", g.Day, g.Model, g.Requests, g.Errors, g.InputTokens, g.OutputTokens, g.AvgLatencyMs)
+			}
+			fmt.Fprintf(w, "TOTAL\t\t%d\t%d\t%d\t%d\t\t\n", report.Requests, report.Errors, report.InputTokens, report.OutputTokens)
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&ledgerFile, "file", "", "usage ledger (defaults to USAGE_LEDGER_PATH)")
+	cmd.Flags().StringVar(&key, "key", "", "only this client (proxy key id, or token:/ip: client id)")
+	cmd.Flags().StringVar(&from, "from", "", "start date (YYYY-MM-DD or RFC 3339)")
+	cmd.Flags().StringVar(&to, "to", "", "end date, inclusive for YYYY-MM-DD")
+
+	return cmd
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -14,6 +15,7 @@ import (
 	"sider2api/internal/config"
 	"sider2api/internal/converter"
 	appLog "sider2api/internal/log"
+	"sider2api/internal/models"
 	"sider2api/internal/session"
 	"sider2api/internal/siderclient"
 	"sider2api/pkg/types"
@@ -29,8 +31,6 @@ var (
 	promptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("36")).Bold(true)
 )
 
-const defaultModelName = "claude-4.5-sonnet"
-
 // tea messages
 type chatResultMsg struct {
 	resp types.SiderParsedResponse
@@ -75,7 +75,7 @@ func initialModel(cfg config.Config, client *siderclient.Client, sessions *sessi
 		sessions:   sessions,
 		input:      ti,
 		viewport:   vp,
-		modelName:  defaultModelName,
+		modelName:  modelRegistry.Default().ID,
 		think:      true,
 		search:     false,
 		messages:   []string{"Welcome to Sider2API TUI. Use /model <name>, /models, /think on|off, /search on|off, /reset, /exit."},
@@ -151,6 +151,7 @@ func (m *model) sendUserMessage(line string) (tea.Model, tea.Cmd) {
 			ConversationID:  m.conversationID,
 			ParentMessageID: m.parentMessageID,
 			ContinuousCID:   m.cfg.ContinuousCID,
+			Models:          modelRegistry,
 		})
 		if err != nil {
 			return chatErrorMsg{err}
@@ -249,24 +250,17 @@ func (m model) View() string {
 }
 
 // Helpers
+
+// modelRegistry is the built-in model list until main loads MODELS_FILE.
+var modelRegistry = models.Builtin()
+
 func availableModels() []string {
-	return []string{
-		"gemini-2.5-flash",
-		"claude-haiku-4.5",
-		"gpt-5-mini",
-		"gpt-5.1",
-		"claude-4.5-sonnet",
-		"gemini-3.0-pro",
-	}
+	return modelRegistry.IDs()
 }
 
 func isAllowedModel(name string) bool {
-	for _, m := range availableModels() {
-		if m == name {
-			return true
-		}
-	}
-	return false
+	_, ok := modelRegistry.Lookup(name)
+	return ok
 }
 
 func main() {
@@ -280,11 +274,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger := appLog.New(cfg.LogLevel)
-	_ = logger
+	// the alternate screen owns the terminal, so logs are discarded
+	logger := appLog.NewWriter(cfg.LogLevel, io.Discard)
 
 	sessions := session.NewSiderSessionManager(cfg.SiderSessionMaxAge, cfg.ContinuousCID)
-	client := siderclient.New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sessions)
+	sessions.SetLimits(cfg.MaxSessions, cfg.MaxSessionsPerOwner)
+	client, err := siderclient.NewFromConfig(cfg, sessions, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		os.Exit(1)
+	}
+	if modelRegistry, err = models.Load(cfg.ModelsFile); err != nil {
+		fmt.Fprintf(os.Stderr, "models error: %v\n", err)
+		os.Exit(1)
+	}
 
 	p := tea.NewProgram(initialModel(cfg, client, sessions), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,295 @@
+package apikeys
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Prefix marks proxy-issued keys so they are never mistaken for Sider tokens.
+const Prefix = "s2a-"
+
+var (
+    ErrInvalidKey      = errors.New("invalid API key")
+    ErrKeyExpired      = errors.New("API key has expired")
+    ErrKeyRevoked      = errors.New("API key has been revoked")
+    ErrKeyNotFound     = errors.New("API key not found")
+    ErrModelNotAllowed = errors.New("model is not allowed for this API key")
+)
+
+// Key is one proxy API key. Only the SHA-256 of the secret is stored.
+type Key struct {
+    ID         string   `json:"id"`
+    Name       string   `json:"name,omitempty"`
+    Hash       string   `json:"hash"`
+    Hint       string   `json:"hint"`
+    SiderToken string   `json:"sider_token,omitempty"`
+    UsePool    bool     `json:"use_pool,omitempty"`
+    Models     []string `json:"models,omitempty"`
+    // Profile names the upstream header profile used with SiderToken.
+    Profile string `json:"profile,omitempty"`
+    // Per-key rate limits; zero uses the server defaults.
+    RPM         int `json:"rpm,omitempty"`
+    TPM         int `json:"tpm,omitempty"`
+    Concurrency int `json:"concurrency,omitempty"`
+    // Per-key quotas per UTC day and calendar month; zero uses the server defaults.
+    DailyTokens     int        `json:"daily_tokens,omitempty"`
+    MonthlyTokens   int        `json:"monthly_tokens,omitempty"`
+    DailyRequests   int        `json:"daily_requests,omitempty"`
+    MonthlyRequests int        `json:"monthly_requests,omitempty"`
+    CreatedAt       time.Time  `json:"created_at"`
+    ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+    RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+// AllowsModel reports whether model may be used with k. An empty list allows all.
+func (k Key) AllowsModel(model string) bool {
+    if len(k.Models) == 0 {
+        return true
+    }
+    for _, m := range k.Models {
+        if strings.EqualFold(m, model) || (strings.HasSuffix(m, "*") && strings.HasPrefix(strings.ToLower(model), strings.ToLower(strings.TrimSuffix(m, "*")))) {
+            return true
+        }
+    }
+    return false
+}
+
+// Status is "active", "expired" or "revoked".
+func (k Key) Status(now time.Time) string {
+    switch {
+    case k.RevokedAt != nil:
+        return "revoked"
+    case k.ExpiresAt != nil && now.After(*k.ExpiresAt):
+        return "expired"
+    }
+    return "active"
+}
+
+// CreateOptions describes a new key.
+type CreateOptions struct {
+    Name            string
+    SiderToken      string
+    UsePool         bool
+    Models          []string
+    Profile         string
+    TTL             time.Duration
+    RPM             int
+    TPM             int
+    Concurrency     int
+    DailyTokens     int
+    MonthlyTokens   int
+    DailyRequests   int
+    MonthlyRequests int
+}
+
+// Store is the keys file. It reloads itself when the file changes on disk, so
+// keys created or revoked by the CLI apply to a running server.
+type Store struct {
+    path    string
+    mu      sync.Mutex
+    keys    []Key
+    modTime time.Time
+    size    int64
+}
+
+// Open loads the keys file at path; a missing file is an empty store.
+func Open(path string) (*Store, error) {
+    s := &Store{path: path}
+    if err := s.reload(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+// Path returns the keys file location.
+func (s *Store) Path() string {
+    return s.path
+}
+
+func (s *Store) reload() error {
+    info, err := os.Stat(s.path)
+    if errors.Is(err, os.ErrNotExist) {
+        s.keys, s.modTime, s.size = nil, time.Time{}, 0
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("stat keys file: %w", err)
+    }
+    if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+        return nil
+    }
+    data, err := os.ReadFile(s.path)
+    if err != nil {
+        return fmt.Errorf("read keys file: %w", err)
+    }
+    var keys []Key
+    if len(strings.TrimSpace(string(data))) > 0 {
+        if err := json.Unmarshal(data, &keys); err != nil {
+            return fmt.Errorf("parse keys file: %w", err)
+        }
+    }
+    s.keys, s.modTime, s.size = keys, info.ModTime(), info.Size()
+    return nil
+}
+
+// save writes the file atomically with owner-only permissions.
+func (s *Store) save() error {
+    if dir := filepath.Dir(s.path); dir != "" {
+        if err := os.MkdirAll(dir, 0o700); err != nil {
+            return fmt.Errorf("create keys dir: %w", err)
+        }
+    }
+    data, err := json.MarshalIndent(s.keys, "", "  ")
+    if err != nil {
+        return err
+    }
+    tmp := s.path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0o600); err != nil {
+        return fmt.Errorf("write keys file: %w", err)
+    }
+    if err := os.Rename(tmp, s.path); err != nil {
+        return fmt.Errorf("replace keys file: %w", err)
+    }
+    if info, err := os.Stat(s.path); err == nil {
+        s.modTime, s.size = info.ModTime(), info.Size()
+    }
+    return nil
+}
+
+// Create adds a key and returns its secret, which is not recoverable afterwards.
+func (s *Store) Create(opts CreateOptions) (string, Key, error) {
+    if opts.SiderToken == "" && !opts.UsePool {
+        return "", Key{}, errors.New("a key needs a Sider token or the token pool")
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if err := s.reload(); err != nil {
+        return "", Key{}, err
+    }
+
+    id, err := randomHex(4)
+    if err != nil {
+        return "", Key{}, err
+    }
+    secret, err := randomHex(24)
+    if err != nil {
+        return "", Key{}, err
+    }
+    plain := Prefix + id + "-" + secret
+    k := Key{
+        ID:              id,
+        Name:            opts.Name,
+        Hash:            hashKey(plain),
+        Hint:            plain[:len(Prefix)+len(id)+5] + "…" + plain[len(plain)-4:],
+        SiderToken:      opts.SiderToken,
+        UsePool:         opts.UsePool,
+        Models:          opts.Models,
+        Profile:         opts.Profile,
+        RPM:             opts.RPM,
+        TPM:             opts.TPM,
+        Concurrency:     opts.Concurrency,
+        DailyTokens:     opts.DailyTokens,
+        MonthlyTokens:   opts.MonthlyTokens,
+        DailyRequests:   opts.DailyRequests,
+        MonthlyRequests: opts.MonthlyRequests,
+        CreatedAt:       time.Now().UTC(),
+    }
+    if opts.TTL > 0 {
+        exp := k.CreatedAt.Add(opts.TTL)
+        k.ExpiresAt = &exp
+    }
+    s.keys = append(s.keys, k)
+    if err := s.save(); err != nil {
+        return "", Key{}, err
+    }
+    return plain, k, nil
+}
+
+// List returns all keys, including revoked and expired ones.
+func (s *Store) List() ([]Key, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if err := s.reload(); err != nil {
+        return nil, err
+    }
+    return append([]Key(nil), s.keys...), nil
+}
+
+// Revoke marks the key with the given id as revoked.
+func (s *Store) Revoke(id string) (Key, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if err := s.reload(); err != nil {
+        return Key{}, err
+    }
+    for i := range s.keys {
+        if s.keys[i].ID != id {
+            continue
+        }
+        if s.keys[i].RevokedAt == nil {
+            now := time.Now().UTC()
+            s.keys[i].RevokedAt = &now
+            if err := s.save(); err != nil {
+                return Key{}, err
+            }
+        }
+        return s.keys[i], nil
+    }
+    return Key{}, ErrKeyNotFound
+}
+
+// Authenticate resolves a presented secret to its key. Every stored hash is
+// compared in constant time so timing does not reveal which keys exist.
+func (s *Store) Authenticate(secret string) (Key, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if err := s.reload(); err != nil {
+        return Key{}, err
+    }
+    presented := []byte(hashKey(secret))
+    match := -1
+    for i := range s.keys {
+        if subtle.ConstantTimeCompare(presented, []byte(s.keys[i].Hash)) == 1 {
+            match = i
+        }
+    }
+    if match < 0 {
+        return Key{}, ErrInvalidKey
+    }
+    k := s.keys[match]
+    switch k.Status(time.Now()) {
+    case "revoked":
+        return k, ErrKeyRevoked
+    case "expired":
+        return k, ErrKeyExpired
+    }
+    return k, nil
+}
+
+// IsProxyKey reports whether a bearer token looks like a proxy-issued key.
+func IsProxyKey(token string) bool {
+    return strings.HasPrefix(token, Prefix)
+}
+
+func hashKey(secret string) string {
+    sum := sha256.Sum256([]byte(secret))
+    return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return "", fmt.Errorf("generate key: %w", err)
+    }
+    return hex.EncodeToString(b), nil
+}
@@ -27,6 +27,63 @@ type Config struct {
     SessionMaxAge     time.Duration
     SiderSessionMaxAge time.Duration
     ContinuousCID     string
+    ConversationQueueDepth  int
+    ConversationWaitTimeout time.Duration
+    SessionStore      string
+    SessionStorePath  string
+    MaxSessions       int
+    MaxSessionsPerOwner int
+    AdminToken        string
+    RetryMaxAttempts  int
+    RetryBaseDelay    time.Duration
+    RetryMaxDelay     time.Duration
+    RetryOn           string
+    SiderTokens       string
+    SiderTokensFile   string
+    TokenStrategy     string
+    TokenCooldown     time.Duration
+    APIKeysFile       string
+    AuthMode          string
+    RateLimitRPM         int
+    RateLimitTPM         int
+    RateLimitConcurrency int
+    GlobalRPM            int
+    GlobalTPM            int
+    GlobalConcurrency    int
+    UsageLedgerPath      string
+    QuotaDailyTokens     int
+    QuotaMonthlyTokens   int
+    QuotaDailyRequests   int
+    QuotaMonthlyRequests int
+    Headers              HeaderProfile
+    HeaderProfilesFile   string
+    HeaderProfile        string
+    UpstreamProxy               string
+    UpstreamCABundle            string
+    UpstreamMaxIdleConns        int
+    UpstreamMaxIdleConnsPerHost int
+    UpstreamIdleConnTimeout     time.Duration
+    UpstreamDialTimeout         time.Duration
+    UpstreamTLSHandshakeTimeout time.Duration
+    UpstreamHTTP2               bool
+    BreakerEnabled              bool
+    BreakerWindow               time.Duration
+    BreakerMinRequests          int
+    BreakerErrorRate            float64
+    BreakerSlowThreshold        time.Duration
+    BreakerSlowRate             float64
+    BreakerOpenFor              time.Duration
+    SSEBufferSize               int
+    SSEMaxEventSize             int
+    VCRMode                     string
+    VCRDir                      string
+    OfflineTokens               string
+    OfflineScenarios            string
+    OfflineDelay                time.Duration
+    ProvidersFile               string
+    ModelsFile                  string
+    ModelsURL                   string
+    ModelsTTL                   time.Duration
 }
 
 // Defaults returns baseline configuration.
@@ -46,6 +103,41 @@ func Defaults() Config {
         SessionMaxAge:      24 * time.Hour,
         SiderSessionMaxAge: 2 * time.Hour,
         ContinuousCID:      "continuous-conversation",
+        ConversationQueueDepth:  4,
+        ConversationWaitTimeout: 30 * time.Second,
+        SessionStore:       "memory",
+        SessionStorePath:   "data/sessions.jsonl",
+        MaxSessions:        10000,
+        MaxSessionsPerOwner: 1000,
+        RetryMaxAttempts:   3,
+        RetryBaseDelay:     500 * time.Millisecond,
+        RetryMaxDelay:      5 * time.Second,
+        RetryOn:            "unavailable,timeout,incomplete",
+        TokenStrategy:      "sticky",
+        TokenCooldown:      10 * time.Minute,
+        APIKeysFile:        "data/api_keys.json",
+        AuthMode:           "auto",
+        UsageLedgerPath:    "data/usage.jsonl",
+        Headers:            DefaultHeaderProfile(),
+        UpstreamMaxIdleConns:        100,
+        UpstreamMaxIdleConnsPerHost: 16,
+        UpstreamIdleConnTimeout:     90 * time.Second,
+        UpstreamDialTimeout:         10 * time.Second,
+        UpstreamTLSHandshakeTimeout: 10 * time.Second,
+        UpstreamHTTP2:               true,
+        BreakerEnabled:              true,
+        BreakerWindow:               time.Minute,
+        BreakerMinRequests:          10,
+        BreakerErrorRate:            0.5,
+        BreakerSlowThreshold:        30 * time.Second,
+        BreakerSlowRate:             0.8,
+        BreakerOpenFor:              30 * time.Second,
+        SSEBufferSize:               64 << 10,
+        SSEMaxEventSize:             16 << 20,
+        VCRMode:                     "off",
+        VCRDir:                      "testdata/fixtures",
+        OfflineTokens:               "dummy",
+        ModelsTTL:                   time.Hour,
     }
 }
 
@@ -108,6 +200,255 @@ func (c *Config) ApplyEnv() {
     if v := os.Getenv("CONTINUOUS_CID"); v != "" {
         c.ContinuousCID = v
     }
+    if v := os.Getenv("CONV_QUEUE_DEPTH"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.ConversationQueueDepth = n
+        }
+    }
+    if v := os.Getenv("CONV_WAIT_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.ConversationWaitTimeout = d
+        }
+    }
+    if v := os.Getenv("SESSION_STORE"); v != "" {
+        c.SessionStore = v
+    }
+    if v := os.Getenv("SESSION_STORE_PATH"); v != "" {
+        c.SessionStorePath = v
+    }
+    if v := os.Getenv("ADMIN_TOKEN"); v != "" {
+        c.AdminToken = v
+    }
+    if v := os.Getenv("API_KEYS_FILE"); v != "" {
+        c.APIKeysFile = v
+    }
+    if v := os.Getenv("AUTH_MODE"); v != "" {
+        c.AuthMode = v
+    }
+    if v := os.Getenv("RATE_LIMIT_RPM"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.RateLimitRPM = n
+        }
+    }
+    if v := os.Getenv("RATE_LIMIT_TPM"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.RateLimitTPM = n
+        }
+    }
+    if v := os.Getenv("RATE_LIMIT_CONCURRENCY"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.RateLimitConcurrency = n
+        }
+    }
+    if v := os.Getenv("GLOBAL_RATE_LIMIT_RPM"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.GlobalRPM = n
+        }
+    }
+    if v := os.Getenv("GLOBAL_RATE_LIMIT_TPM"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.GlobalTPM = n
+        }
+    }
+    if v := os.Getenv("GLOBAL_RATE_LIMIT_CONCURRENCY"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.GlobalConcurrency = n
+        }
+    }
+    if v := os.Getenv("USAGE_LEDGER_PATH"); v != "" {
+        c.UsageLedgerPath = v
+    }
+    if v := os.Getenv("QUOTA_DAILY_TOKENS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.QuotaDailyTokens = n
+        }
+    }
+    if v := os.Getenv("QUOTA_MONTHLY_TOKENS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.QuotaMonthlyTokens = n
+        }
+    }
+    if v := os.Getenv("QUOTA_DAILY_REQUESTS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.QuotaDailyRequests = n
+        }
+    }
+    if v := os.Getenv("QUOTA_MONTHLY_REQUESTS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.QuotaMonthlyRequests = n
+        }
+    }
+    if v := os.Getenv("SIDER_ORIGIN"); v != "" {
+        c.Headers.Origin = v
+    }
+    if v := os.Getenv("SIDER_USER_AGENT"); v != "" {
+        c.Headers.UserAgent = v
+    }
+    if v := os.Getenv("SIDER_APP_NAME"); v != "" {
+        c.Headers.AppName = v
+    }
+    if v := os.Getenv("SIDER_APP_VERSION"); v != "" {
+        c.Headers.AppVersion = v
+    }
+    if v := os.Getenv("SIDER_TIME_ZONE"); v != "" {
+        c.Headers.TimeZone = v
+    }
+    if v := os.Getenv("SIDER_HEADER_PROFILES_FILE"); v != "" {
+        c.HeaderProfilesFile = v
+    }
+    if v := os.Getenv("SIDER_HEADER_PROFILE"); v != "" {
+        c.HeaderProfile = v
+    }
+    if v := os.Getenv("UPSTREAM_PROXY"); v != "" {
+        c.UpstreamProxy = v
+    }
+    if v := os.Getenv("UPSTREAM_CA_BUNDLE"); v != "" {
+        c.UpstreamCABundle = v
+    }
+    if v := os.Getenv("UPSTREAM_MAX_IDLE_CONNS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.UpstreamMaxIdleConns = n
+        }
+    }
+    if v := os.Getenv("UPSTREAM_MAX_IDLE_CONNS_PER_HOST"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.UpstreamMaxIdleConnsPerHost = n
+        }
+    }
+    if v := os.Getenv("UPSTREAM_IDLE_CONN_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.UpstreamIdleConnTimeout = d
+        }
+    }
+    if v := os.Getenv("UPSTREAM_DIAL_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.UpstreamDialTimeout = d
+        }
+    }
+    if v := os.Getenv("UPSTREAM_TLS_HANDSHAKE_TIMEOUT"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.UpstreamTLSHandshakeTimeout = d
+        }
+    }
+    if v := os.Getenv("UPSTREAM_HTTP2"); v != "" {
+        c.UpstreamHTTP2 = v == "1" || v == "true"
+    }
+    if v := os.Getenv("BREAKER_ENABLED"); v != "" {
+        c.BreakerEnabled = v == "1" || v == "true"
+    }
+    if v := os.Getenv("BREAKER_WINDOW"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.BreakerWindow = d
+        }
+    }
+    if v := os.Getenv("BREAKER_MIN_REQUESTS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.BreakerMinRequests = n
+        }
+    }
+    if v := os.Getenv("BREAKER_ERROR_RATE"); v != "" {
+        if f, err := strconv.ParseFloat(v, 64); err == nil {
+            c.BreakerErrorRate = f
+        }
+    }
+    if v := os.Getenv("BREAKER_SLOW_THRESHOLD"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.BreakerSlowThreshold = d
+        }
+    }
+    if v := os.Getenv("BREAKER_SLOW_RATE"); v != "" {
+        if f, err := strconv.ParseFloat(v, 64); err == nil {
+            c.BreakerSlowRate = f
+        }
+    }
+    if v := os.Getenv("BREAKER_OPEN_FOR"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.BreakerOpenFor = d
+        }
+    }
+    if v := os.Getenv("SSE_BUFFER_SIZE"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.SSEBufferSize = n
+        }
+    }
+    if v := os.Getenv("SSE_MAX_EVENT_SIZE"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.SSEMaxEventSize = n
+        }
+    }
+    if v := os.Getenv("VCR_MODE"); v != "" {
+        c.VCRMode = v
+    }
+    if v := os.Getenv("VCR_DIR"); v != "" {
+        c.VCRDir = v
+    }
+    if v, ok := os.LookupEnv("OFFLINE_TOKENS"); ok {
+        c.OfflineTokens = v
+    }
+    if v := os.Getenv("OFFLINE_SCENARIOS"); v != "" {
+        c.OfflineScenarios = v
+    }
+    if v := os.Getenv("OFFLINE_DELAY"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.OfflineDelay = d
+        }
+    }
+    if v := os.Getenv("PROVIDERS_FILE"); v != "" {
+        c.ProvidersFile = v
+    }
+    if v := os.Getenv("MODELS_FILE"); v != "" {
+        c.ModelsFile = v
+    }
+    if v := os.Getenv("SIDER_MODELS_URL"); v != "" {
+        c.ModelsURL = v
+    }
+    if v := os.Getenv("MODELS_TTL"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.ModelsTTL = d
+        }
+    }
+    if v := os.Getenv("SIDER_TOKENS"); v != "" {
+        c.SiderTokens = v
+    }
+    if v := os.Getenv("SIDER_TOKENS_FILE"); v != "" {
+        c.SiderTokensFile = v
+    }
+    if v := os.Getenv("TOKEN_STRATEGY"); v != "" {
+        c.TokenStrategy = v
+    }
+    if v := os.Getenv("TOKEN_COOLDOWN"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.TokenCooldown = d
+        }
+    }
+    if v := os.Getenv("RETRY_MAX_ATTEMPTS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.RetryMaxAttempts = n
+        }
+    }
+    if v := os.Getenv("RETRY_BASE_DELAY"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.RetryBaseDelay = d
+        }
+    }
+    if v := os.Getenv("RETRY_MAX_DELAY"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            c.RetryMaxDelay = d
+        }
+    }
+    if v := os.Getenv("RETRY_ON"); v != "" {
+        c.RetryOn = v
+    }
+    if v := os.Getenv("MAX_SESSIONS"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.MaxSessions = n
+        }
+    }
+    if v := os.Getenv("MAX_SESSIONS_PER_OWNER"); v != "" {
+        if n, err := strconv.Atoi(v); err == nil {
+            c.MaxSessionsPerOwner = n
+        }
+    }
 }
 
 // Parse builds config from env + flags. Flags override env, which override defaults.
@@ -140,6 +481,64 @@ func Parse(args []string) (Config, error) {
     fs.DurationVar(&cfg.SessionMaxAge, "session-max-age", cfg.SessionMaxAge, "conversation session max age")
     fs.DurationVar(&cfg.SiderSessionMaxAge, "sider-session-max-age", cfg.SiderSessionMaxAge, "sider session max age")
     fs.StringVar(&cfg.ContinuousCID, "continuous-cid", cfg.ContinuousCID, "reserved CID for inferred continuous conversations")
+    fs.IntVar(&cfg.ConversationQueueDepth, "conv-queue-depth", cfg.ConversationQueueDepth, "max requests waiting behind an in-flight request on the same conversation")
+    fs.DurationVar(&cfg.ConversationWaitTimeout, "conv-wait-timeout", cfg.ConversationWaitTimeout, "max time a request waits for its turn on a conversation")
+    fs.StringVar(&cfg.SessionStore, "session-store", cfg.SessionStore, "session store backend (memory,file)")
+    fs.StringVar(&cfg.SessionStorePath, "session-store-path", cfg.SessionStorePath, "session store file for the file backend")
+    fs.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken, "bearer token for /admin endpoints (empty disables them)")
+    fs.StringVar(&cfg.APIKeysFile, "api-keys-file", cfg.APIKeysFile, "proxy API keys file")
+    fs.StringVar(&cfg.AuthMode, "auth-mode", cfg.AuthMode, "auto (keys when the keys file exists), passthrough (Sider tokens or proxy keys) or keys (proxy keys only)")
+    fs.IntVar(&cfg.RateLimitRPM, "rate-limit-rpm", cfg.RateLimitRPM, "requests per minute per client (0 = unlimited)")
+    fs.IntVar(&cfg.RateLimitTPM, "rate-limit-tpm", cfg.RateLimitTPM, "tokens per minute per client (0 = unlimited)")
+    fs.IntVar(&cfg.RateLimitConcurrency, "rate-limit-concurrency", cfg.RateLimitConcurrency, "concurrent streams per client (0 = unlimited)")
+    fs.IntVar(&cfg.GlobalRPM, "global-rate-limit-rpm", cfg.GlobalRPM, "requests per minute across all clients (0 = unlimited)")
+    fs.IntVar(&cfg.GlobalTPM, "global-rate-limit-tpm", cfg.GlobalTPM, "tokens per minute across all clients (0 = unlimited)")
+    fs.IntVar(&cfg.GlobalConcurrency, "global-rate-limit-concurrency", cfg.GlobalConcurrency, "concurrent streams across all clients (0 = unlimited)")
+    fs.StringVar(&cfg.UsageLedgerPath, "usage-ledger", cfg.UsageLedgerPath, "usage ledger file (empty keeps usage in memory)")
+    fs.IntVar(&cfg.QuotaDailyTokens, "quota-daily-tokens", cfg.QuotaDailyTokens, "tokens per client per UTC day (0 = unlimited)")
+    fs.IntVar(&cfg.QuotaMonthlyTokens, "quota-monthly-tokens", cfg.QuotaMonthlyTokens, "tokens per client per month (0 = unlimited)")
+    fs.IntVar(&cfg.QuotaDailyRequests, "quota-daily-requests", cfg.QuotaDailyRequests, "requests per client per UTC day (0 = unlimited)")
+    fs.IntVar(&cfg.QuotaMonthlyRequests, "quota-monthly-requests", cfg.QuotaMonthlyRequests, "requests per client per month (0 = unlimited)")
+    fs.StringVar(&cfg.Headers.AppVersion, "sider-app-version", cfg.Headers.AppVersion, "X-App-Version sent to Sider")
+    fs.StringVar(&cfg.Headers.TimeZone, "sider-time-zone", cfg.Headers.TimeZone, "X-Time-Zone sent to Sider (default: local zone)")
+    fs.StringVar(&cfg.HeaderProfilesFile, "header-profiles", cfg.HeaderProfilesFile, "JSON file of named upstream header profiles")
+    fs.StringVar(&cfg.HeaderProfile, "header-profile", cfg.HeaderProfile, "default header profile name from the profiles file")
+    fs.StringVar(&cfg.UpstreamProxy, "upstream-proxy", cfg.UpstreamProxy, "proxy for Sider requests (http://, https://, socks5://); default uses HTTPS_PROXY")
+    fs.StringVar(&cfg.UpstreamCABundle, "upstream-ca-bundle", cfg.UpstreamCABundle, "PEM file of extra CA certificates trusted for Sider requests")
+    fs.IntVar(&cfg.UpstreamMaxIdleConns, "upstream-max-idle-conns", cfg.UpstreamMaxIdleConns, "max idle upstream connections (0 = unlimited)")
+    fs.IntVar(&cfg.UpstreamMaxIdleConnsPerHost, "upstream-max-idle-conns-per-host", cfg.UpstreamMaxIdleConnsPerHost, "max idle upstream connections per host")
+    fs.DurationVar(&cfg.UpstreamIdleConnTimeout, "upstream-idle-conn-timeout", cfg.UpstreamIdleConnTimeout, "how long idle upstream connections are kept")
+    fs.DurationVar(&cfg.UpstreamDialTimeout, "upstream-dial-timeout", cfg.UpstreamDialTimeout, "upstream connect timeout")
+    fs.DurationVar(&cfg.UpstreamTLSHandshakeTimeout, "upstream-tls-timeout", cfg.UpstreamTLSHandshakeTimeout, "upstream TLS handshake timeout")
+    fs.BoolVar(&cfg.UpstreamHTTP2, "upstream-http2", cfg.UpstreamHTTP2, "use HTTP/2 for upstream requests when available")
+    fs.BoolVar(&cfg.BreakerEnabled, "breaker", cfg.BreakerEnabled, "fail fast while Sider is unhealthy")
+    fs.DurationVar(&cfg.BreakerWindow, "breaker-window", cfg.BreakerWindow, "window over which upstream error and slow rates are measured")
+    fs.IntVar(&cfg.BreakerMinRequests, "breaker-min-requests", cfg.BreakerMinRequests, "calls in the window before the breaker may trip")
+    fs.Float64Var(&cfg.BreakerErrorRate, "breaker-error-rate", cfg.BreakerErrorRate, "failure fraction that opens the breaker")
+    fs.DurationVar(&cfg.BreakerSlowThreshold, "breaker-slow-threshold", cfg.BreakerSlowThreshold, "time to first event above which a call counts as slow (0 disables)")
+    fs.Float64Var(&cfg.BreakerSlowRate, "breaker-slow-rate", cfg.BreakerSlowRate, "slow-call fraction that opens the breaker")
+    fs.DurationVar(&cfg.BreakerOpenFor, "breaker-open-for", cfg.BreakerOpenFor, "how long the breaker stays open before a probe")
+    fs.IntVar(&cfg.SSEBufferSize, "sse-buffer-size", cfg.SSEBufferSize, "initial buffer in bytes for reading the upstream event stream")
+    fs.IntVar(&cfg.SSEMaxEventSize, "sse-max-event-size", cfg.SSEMaxEventSize, "largest upstream event in bytes before the stream is aborted")
+    fs.StringVar(&cfg.VCRMode, "vcr", cfg.VCRMode, "upstream fixtures: off, record or replay")
+    fs.StringVar(&cfg.VCRDir, "vcr-dir", cfg.VCRDir, "directory for recorded upstream fixtures")
+    fs.StringVar(&cfg.OfflineTokens, "offline-tokens", cfg.OfflineTokens, "comma-separated tokens answered by the built-in offline backend")
+    fs.StringVar(&cfg.OfflineScenarios, "offline-scenarios", cfg.OfflineScenarios, "YAML scenarios for the offline backend (default: built-in)")
+    fs.DurationVar(&cfg.OfflineDelay, "offline-delay", cfg.OfflineDelay, "delay between offline stream events; 0 keeps the scenario delays")
+    fs.StringVar(&cfg.ProvidersFile, "providers", cfg.ProvidersFile, "JSON file of extra model providers, e.g. local OpenAI-compatible servers")
+    fs.StringVar(&cfg.ModelsFile, "models-file", cfg.ModelsFile, "JSON file overriding the built-in model registry")
+    fs.StringVar(&cfg.ModelsURL, "models-url", cfg.ModelsURL, "Sider model list endpoint for live model discovery (empty disables)")
+    fs.DurationVar(&cfg.ModelsTTL, "models-ttl", cfg.ModelsTTL, "how long a discovered model list is cached")
+    fs.StringVar(&cfg.SiderTokens, "tokens", cfg.SiderTokens, "comma-separated Sider token pool")
+    fs.StringVar(&cfg.SiderTokensFile, "tokens-file", cfg.SiderTokensFile, "Sider token pool file (one per line, or JSON)")
+    fs.StringVar(&cfg.TokenStrategy, "token-strategy", cfg.TokenStrategy, "token selection (round-robin,least-used,sticky)")
+    fs.DurationVar(&cfg.TokenCooldown, "token-cooldown", cfg.TokenCooldown, "how long a token that failed auth or quota is skipped")
+    fs.IntVar(&cfg.RetryMaxAttempts, "retry-max-attempts", cfg.RetryMaxAttempts, "max upstream attempts per request (1 disables retries)")
+    fs.DurationVar(&cfg.RetryBaseDelay, "retry-base-delay", cfg.RetryBaseDelay, "initial retry backoff")
+    fs.DurationVar(&cfg.RetryMaxDelay, "retry-max-delay", cfg.RetryMaxDelay, "maximum retry backoff")
+    fs.StringVar(&cfg.RetryOn, "retry-on", cfg.RetryOn, "error kinds to retry (unavailable,timeout,incomplete,rate_limit,stream)")
+    fs.IntVar(&cfg.MaxSessions, "max-sessions", cfg.MaxSessions, "max tracked sessions before LRU eviction (0 = unlimited)")
+    fs.IntVar(&cfg.MaxSessionsPerOwner, "max-sessions-per-owner", cfg.MaxSessionsPerOwner, "max sessions per auth token (0 = unlimited)")
 
     if err := fs.Parse(args); err != nil {
         // propagate flag errors to caller for CLI to display
@@ -183,3 +582,8 @@ func loadDotEnv(path string) (bool, error) {
     }
     return true, nil
 }
+
+// HasTokenPool reports whether a Sider token pool is configured.
+func (c Config) HasTokenPool() bool {
+    return c.SiderTokens != "" || c.SiderTokensFile != ""
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+)
+
+// HeaderProfile is the browser-extension identity presented to Sider on every
+// upstream request. Empty fields inherit from the default profile.
+type HeaderProfile struct {
+    Origin     string `json:"origin,omitempty"`
+    UserAgent  string `json:"user_agent,omitempty"`
+    AppName    string `json:"app_name,omitempty"`
+    AppVersion string `json:"app_version,omitempty"`
+    // TimeZone is sent as X-Time-Zone; empty uses the server's local zone.
+    TimeZone string            `json:"time_zone,omitempty"`
+    Extra    map[string]string `json:"headers,omitempty"`
+}
+
+// DefaultHeaderProfile mirrors the Sider Edge extension.
+func DefaultHeaderProfile() HeaderProfile {
+    return HeaderProfile{
+        Origin:     "chrome-extension://dhoenijjpgpeimemopealfcbiecgceod",
+        UserAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0",
+        AppName:    "ChitChat_Edge_Ext",
+        AppVersion: "5.13.0",
+    }
+}
+
+// Inherit fills empty fields of p from base.
+func (p HeaderProfile) Inherit(base HeaderProfile) HeaderProfile {
+    if p.Origin == "" {
+        p.Origin = base.Origin
+    }
+    if p.UserAgent == "" {
+        p.UserAgent = base.UserAgent
+    }
+    if p.AppName == "" {
+        p.AppName = base.AppName
+    }
+    if p.AppVersion == "" {
+        p.AppVersion = base.AppVersion
+    }
+    if p.TimeZone == "" {
+        p.TimeZone = base.TimeZone
+    }
+    if len(base.Extra) > 0 {
+        extra := make(map[string]string, len(base.Extra)+len(p.Extra))
+        for k, v := range base.Extra {
+            extra[k] = v
+        }
+        for k, v := range p.Extra {
+            extra[k] = v
+        }
+        p.Extra = extra
+    }
+    return p
+}
+
+// LoadHeaderProfiles reads named profiles from a JSON object of name -> profile.
+// Each profile inherits unset fields from base.
+func LoadHeaderProfiles(path string, base HeaderProfile) (map[string]HeaderProfile, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read header profiles: %w", err)
+    }
+    var raw map[string]HeaderProfile
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("parse header profiles: %w", err)
+    }
+    profiles := make(map[string]HeaderProfile, len(raw))
+    for name, p := range raw {
+        profiles[name] = p.Inherit(base)
+    }
+    return profiles, nil
+}
@@ -6,6 +6,7 @@ import (
     "regexp"
     "strings"
 
+    "sider2api/internal/models"
     "sider2api/pkg/types"
 )
 
@@ -14,6 +15,11 @@ type ConvertOptions struct {
     ConversationID  string
     ParentMessageID string
     ContinuousCID   string
+    // Models resolves model names and their capabilities; nil uses the built-in registry.
+    Models *models.Registry
+    // CapabilityModel is the model the request is checked against when it differs
+    // from the requested one, e.g. the first target of a routed alias.
+    CapabilityModel string
 }
 
 // ConvertAnthropicToSider builds a SiderRequest from an AnthropicRequest (non-historical path).
@@ -29,7 +35,7 @@ func ConvertAnthropicToSider(req types.AnthropicRequest, opts ConvertOptions) (t
     lastUser := userMessages[len(userMessages)-1]
     currentUserInput := ExtractTextContent(lastUser.Content)
 
-    siderModel := MapModelName(req.Model)
+    siderModel := opts.Models.UpstreamName(req.Model)
     outputLanguage := DetermineOutputLanguage(currentUserInput)
     promptTemplates := DefaultPromptTemplates()
     thinkMode := BuildThinkMode(req)
@@ -58,6 +64,13 @@ func ConvertAnthropicToSider(req types.AnthropicRequest, opts ConvertOptions) (t
         OutputLanguage: outputLanguage,
         ThinkMode:      &types.SiderThinkMode{Enable: thinkMode},
     }
+    capModel := req.Model
+    if opts.CapabilityModel != "" {
+        capModel = opts.CapabilityModel
+    }
+    if err := applyCapabilities(req, capModel, opts.Models, &sr); err != nil {
+        return types.SiderRequest{}, err
+    }
 
     return sr, nil
 }
@@ -109,23 +122,10 @@ func ExtractTextContent(content any) string {
     }
 }
 
-// MapModelName converts Anthropic model names to Sider equivalents.
+// MapModelName converts a requested model name or alias to its Sider name using the
+// built-in registry.
 func MapModelName(model string) string {
-    normalized := strings.ToLower(model)
-    mapping := map[string]string{
-        "claude-3.7-sonnet":      "claude-3.7-sonnet-think",
-        "claude-3-7-sonnet":      "claude-3.7-sonnet-think",
-        "claude-3.7":             "claude-3.7-sonnet-think",
-        "claude-4-sonnet":        "claude-4-sonnet-think",
-        "claude-4":               "claude-4-sonnet-think",
-        "claude-sonnet-4":        "claude-4-sonnet-think",
-        "claude-3-sonnet":        "claude-3.7-sonnet-think",
-        "claude-sonnet":          "claude-3.7-sonnet-think",
-    }
-    if mapped, ok := mapping[normalized]; ok {
-        return mapped
-    }
-    return model
+    return models.Builtin().UpstreamName(model)
 }
 
 // DetermineOutputLanguage infers output language from latest user input.
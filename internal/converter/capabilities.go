@@ -0,0 +1,104 @@
+package converter
+
+import (
+    "encoding/json"
+    "fmt"
+
+    "sider2api/internal/models"
+    "sider2api/pkg/types"
+)
+
+// applyCapabilities checks a converted request against what model supports.
+// Features the model lacks are switched off and reported as warnings on sr; input it
+// cannot take (images for a text-only model, more than its context window) is an
+// error. Models missing from the registry are not checked.
+func applyCapabilities(req types.AnthropicRequest, model string, registry *models.Registry, sr *types.SiderRequest) error {
+    m, ok := registry.Lookup(model)
+    if !ok {
+        return nil
+    }
+
+    if !m.SupportsVision && hasImages(req.Messages) {
+        return fmt.Errorf("model %s does not accept images", m.ID)
+    }
+    if m.ContextWindow > 0 {
+        if est := EstimateInputTokens(req); est > m.ContextWindow {
+            return fmt.Errorf("input is about %d tokens, over the %d-token context window of %s", est, m.ContextWindow, m.ID)
+        }
+    }
+
+    if !m.SupportsThink && sr.ThinkMode != nil && sr.ThinkMode.Enable {
+        sr.ThinkMode.Enable = false
+        sr.Warnings = append(sr.Warnings, fmt.Sprintf("thinking disabled: %s does not support it", m.ID))
+    }
+    if !m.SupportsSearch && dropTool(&sr.Tools, "search") {
+        sr.Tools.Search = nil
+        sr.Warnings = append(sr.Warnings, fmt.Sprintf("web search disabled: %s does not support it", m.ID))
+    }
+    if !m.SupportsImages && dropTool(&sr.Tools, "create_image") {
+        sr.Tools.Image = nil
+        sr.Warnings = append(sr.Warnings, fmt.Sprintf("image generation disabled: %s does not support it", m.ID))
+    }
+    return nil
+}
+
+// dropTool removes name from the auto tool list and reports whether it was there.
+func dropTool(tools *types.SiderTools, name string) bool {
+    found := false
+    kept := tools.Auto[:0]
+    for _, t := range tools.Auto {
+        if t == name {
+            found = true
+            continue
+        }
+        kept = append(kept, t)
+    }
+    tools.Auto = kept
+    return found
+}
+
+func hasImages(messages []types.AnthropicMessage) bool {
+    for _, m := range messages {
+        for _, b := range contentBlocks(m.Content) {
+            if b.Type == "image" {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// EstimateInputTokens is a rough count (4 bytes per token) of the text in the system
+// prompt and every message; images are not counted.
+func EstimateInputTokens(req types.AnthropicRequest) int {
+    n := len(req.System)
+    for _, m := range req.Messages {
+        for _, b := range contentBlocks(m.Content) {
+            n += len(b.Text)
+            for _, inner := range b.Content {
+                n += len(inner.Text)
+            }
+        }
+    }
+    return (n + 3) / 4
+}
+
+// contentBlocks returns message content as blocks: a string becomes one text block,
+// and content decoded from JSON (which arrives as []any) is converted.
+func contentBlocks(content any) []types.AnthropicContent {
+    switch v := content.(type) {
+    case string:
+        return []types.AnthropicContent{{Type: "text", Text: v}}
+    case []types.AnthropicContent:
+        return v
+    case nil:
+        return nil
+    default:
+        var blocks []types.AnthropicContent
+        raw, _ := json.Marshal(v)
+        if json.Unmarshal(raw, &blocks) != nil {
+            return nil
+        }
+        return blocks
+    }
+}
@@ -0,0 +1,108 @@
+package converter
+
+import (
+    "strings"
+
+    "sider2api/pkg/types"
+)
+
+// HistoryThread is the linear thread recovered from an upstream conversation transcript.
+type HistoryThread struct {
+    Messages           []types.AnthropicMessage
+    UserMessageID      string
+    AssistantMessageID string
+    Model              string
+}
+
+// ConvertSiderHistoryToAnthropic orders a transcript into its latest branch and converts
+// multi_content into Anthropic messages. The returned IDs are the last user/assistant
+// messages on that branch, i.e. the parent to continue from.
+func ConvertSiderHistoryToAnthropic(msgs []types.SiderConversationMessage) HistoryThread {
+    var thread HistoryThread
+    chain := latestBranch(msgs)
+
+    for _, m := range chain {
+        role := m.Role
+        if role != "user" && role != "assistant" {
+            continue
+        }
+        text := historyText(m)
+        if text == "" {
+            continue
+        }
+        // merge consecutive same-role turns so the result alternates like the messages API expects
+        if n := len(thread.Messages); n > 0 && thread.Messages[n-1].Role == role {
+            thread.Messages[n-1].Content = thread.Messages[n-1].Content.(string) + "\n\n" + text
+        } else {
+            thread.Messages = append(thread.Messages, types.AnthropicMessage{Role: role, Content: text})
+        }
+        if role == "user" {
+            thread.UserMessageID = m.ID
+        } else {
+            thread.AssistantMessageID = m.ID
+            if m.Model != "" {
+                thread.Model = m.Model
+            }
+        }
+    }
+    return thread
+}
+
+// historyText prefers the raw user input over the context-injected prompt we send upstream.
+func historyText(m types.SiderConversationMessage) string {
+    var b strings.Builder
+    for _, part := range m.MultiContent {
+        text := part.Text
+        if m.Role == "user" && part.UserInputText != "" {
+            text = part.UserInputText
+        }
+        if part.Type != "text" || strings.TrimSpace(text) == "" {
+            continue
+        }
+        if b.Len() > 0 {
+            b.WriteString("\n")
+        }
+        b.WriteString(text)
+    }
+    return strings.TrimSpace(b.String())
+}
+
+// latestBranch returns the root-to-leaf path ending at the deepest leaf; ties go to the
+// leaf that appears last in the transcript. Missing parents (e.g. beyond the fetch limit)
+// simply end the walk.
+func latestBranch(msgs []types.SiderConversationMessage) []types.SiderConversationMessage {
+    byID := make(map[string]types.SiderConversationMessage, len(msgs))
+    isParent := make(map[string]bool, len(msgs))
+    for _, m := range msgs {
+        byID[m.ID] = m
+        if m.ParentMessageID != "" {
+            isParent[m.ParentMessageID] = true
+        }
+    }
+
+    var best []types.SiderConversationMessage
+    for _, m := range msgs {
+        if isParent[m.ID] {
+            continue
+        }
+        path := []types.SiderConversationMessage{m}
+        seen := map[string]bool{m.ID: true}
+        for p := m.ParentMessageID; p != "" && !seen[p]; {
+            parent, ok := byID[p]
+            if !ok {
+                break
+            }
+            seen[p] = true
+            path = append(path, parent)
+            p = parent.ParentMessageID
+        }
+        if len(path) >= len(best) {
+            best = path
+        }
+    }
+
+    for i, j := 0, len(best)-1; i < j; i, j = i+1, j-1 {
+        best[i], best[j] = best[j], best[i]
+    }
+    return best
+}
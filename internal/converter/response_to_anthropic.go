@@ -15,7 +15,7 @@ func ConvertSiderToAnthropic(resp types.SiderParsedResponse, originalModel strin
     usage := estimateUsage(resp, combined)
 
     ar := types.AnthropicResponse{
-        ID:         generateResponseID(),
+        ID:         NewResponseID(),
         Type:       "message",
         Role:       "assistant",
         Content:    []types.AnthropicResponseContent{{Type: "text", Text: combined}},
@@ -56,7 +56,7 @@ func SessionHeadersFromSider(resp types.SiderParsedResponse) map[string]string {
 // CreateErrorResponse wraps an error into AnthropicResponse.
 func CreateErrorResponse(err error, model string) types.AnthropicResponse {
     return types.AnthropicResponse{
-        ID:         generateResponseID(),
+        ID:         NewResponseID(),
         Type:       "message",
         Role:       "assistant",
         Content:    []types.AnthropicResponseContent{{Type: "text", Text: "Error: " + err.Error()}},
@@ -93,7 +93,8 @@ func estimateUsage(resp types.SiderParsedResponse, output string) types.Anthropi
     }
 }
 
-func generateResponseID() string {
+// NewResponseID returns a fresh Anthropic-style message id.
+func NewResponseID() string {
     ts := time.Now().UnixMilli()
     randPart := rand.Intn(1_000_000)
     return fmt.Sprintf("msg_%d_%06d", ts, randPart)
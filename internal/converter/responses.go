@@ -0,0 +1,248 @@
+package converter
+
+import (
+    "encoding/json"
+    "errors"
+    "strings"
+    "time"
+
+    "sider2api/pkg/types"
+)
+
+// ResponsesToAnthropic converts a Responses API request to Anthropic format. System
+// and developer messages are appended to the instructions; reasoning, web_search and
+// function items in the input are earlier output and are skipped, since the
+// conversation already holds them.
+func ResponsesToAnthropic(req types.ResponsesRequest) (types.AnthropicRequest, error) {
+    items, err := responsesInputItems(req.Input)
+    if err != nil {
+        return types.AnthropicRequest{}, err
+    }
+
+    system := []string{}
+    if s := strings.TrimSpace(req.Instructions); s != "" {
+        system = append(system, s)
+    }
+    var messages []types.AnthropicMessage
+    for _, item := range items {
+        switch item.Type {
+        case "", "message":
+            switch item.Role {
+            case "system", "developer":
+                if s := ExtractTextContent(responsesContent(item.Content)); s != "" {
+                    system = append(system, s)
+                }
+            case "user", "assistant":
+                messages = append(messages, types.AnthropicMessage{Role: item.Role, Content: responsesContent(item.Content)})
+            }
+        case "function_call_output":
+            out := item.Output
+            if out == "" {
+                out = "[empty tool result]"
+            }
+            messages = append(messages, types.AnthropicMessage{Role: "user", Content: "Tool result (" + item.CallID + "):\n" + out})
+        }
+    }
+
+    ar := types.AnthropicRequest{
+        Model:       req.Model,
+        Messages:    messages,
+        MaxTokens:   req.MaxOutputTokens,
+        Temperature: req.Temperature,
+        TopP:        req.TopP,
+        Stream:      req.Stream,
+        System:      strings.Join(system, "\n\n"),
+    }
+
+    meta := &types.AnthropicMetadata{}
+    if req.Reasoning != nil {
+        think := req.Reasoning.Effort != "none" && req.Reasoning.Effort != "minimal"
+        meta.ThinkEnabled = &think
+    }
+    for _, t := range req.Tools {
+        switch {
+        case strings.HasPrefix(t.Type, "web_search"):
+            search := true
+            meta.SearchEnabled = &search
+        case t.Type == "image_generation":
+            ar.Tools = append(ar.Tools, types.AnthropicTool{Name: "image_generation", InputSchema: types.AnthropicToolInputSchema{Type: "object"}})
+        case t.Type == "function" && t.Name != "":
+            props, _ := t.Parameters["properties"].(map[string]any)
+            ar.Tools = append(ar.Tools, types.AnthropicTool{
+                Name:        t.Name,
+                Description: t.Description,
+                InputSchema: types.AnthropicToolInputSchema{Type: "object", Properties: props},
+            })
+        }
+    }
+    if meta.ThinkEnabled != nil || meta.SearchEnabled != nil {
+        ar.Metadata = meta
+    }
+    return ar, nil
+}
+
+// responsesInputItems decodes input, which is a string or a list of items.
+func responsesInputItems(input any) ([]types.ResponsesInputItem, error) {
+    switch v := input.(type) {
+    case nil:
+        return nil, errors.New("missing required field: input")
+    case string:
+        return []types.ResponsesInputItem{{Type: "message", Role: "user", Content: v}}, nil
+    default:
+        var items []types.ResponsesInputItem
+        raw, _ := json.Marshal(v)
+        if err := json.Unmarshal(raw, &items); err != nil {
+            return nil, errors.New("input must be a string or a list of input items")
+        }
+        return items, nil
+    }
+}
+
+// responsesContent converts message content to a string, or to Anthropic blocks when
+// it carries images. Only data: URLs can be passed on; other image URLs are kept as
+// a text reference.
+func responsesContent(content any) any {
+    if s, ok := content.(string); ok {
+        return s
+    }
+    var parts []types.ResponsesInputContent
+    raw, _ := json.Marshal(content)
+    if json.Unmarshal(raw, &parts) != nil {
+        return ""
+    }
+    var blocks []types.AnthropicContent
+    hasImage := false
+    for _, p := range parts {
+        switch p.Type {
+        case "input_text", "output_text", "text":
+            blocks = append(blocks, types.AnthropicContent{Type: "text", Text: p.Text})
+        case "input_image":
+            if src := dataURLSource(p.ImageURL); src != nil {
+                blocks = append(blocks, types.AnthropicContent{Type: "image", Source: src})
+                hasImage = true
+            } else if p.ImageURL != "" {
+                blocks = append(blocks, types.AnthropicContent{Type: "text", Text: "[image:" + p.ImageURL + "]"})
+            }
+        }
+    }
+    if hasImage {
+        return blocks
+    }
+    return ExtractTextContent(blocks)
+}
+
+// dataURLSource parses a base64 data: URL into an image source.
+func dataURLSource(url string) *types.AnthropicImageSource {
+    rest, ok := strings.CutPrefix(url, "data:")
+    if !ok {
+        return nil
+    }
+    meta, data, ok := strings.Cut(rest, ",")
+    mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+    if !ok || !isBase64 {
+        return nil
+    }
+    return &types.AnthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+}
+
+// NewResponsesObject returns the in_progress response object for req.
+func NewResponsesObject(id, model string, req types.ResponsesRequest) types.ResponsesResponse {
+    resp := types.ResponsesResponse{
+        ID:        id,
+        Object:    "response",
+        CreatedAt: time.Now().Unix(),
+        Status:    "in_progress",
+        Model:     model,
+        Output:    []types.ResponsesOutputItem{},
+        Metadata:  req.Metadata,
+    }
+    if req.Instructions != "" {
+        resp.Instructions = &req.Instructions
+    }
+    if req.PreviousResponseID != "" {
+        resp.PreviousResponseID = &req.PreviousResponseID
+    }
+    return resp
+}
+
+// CompleteResponsesObject fills in the output items and usage of a finished reply:
+// reasoning first, then web searches, then the message.
+func CompleteResponsesObject(resp *types.ResponsesResponse, sider types.SiderParsedResponse) {
+    suffix := ResponsesItemSuffix(resp.ID)
+    resp.Output = []types.ResponsesOutputItem{}
+    reasoning := strings.Join(sider.ReasoningParts, "")
+    if reasoning != "" {
+        resp.Output = append(resp.Output, ResponsesReasoningItem("rs_"+suffix, reasoning))
+    }
+    for _, tr := range sider.ToolResults {
+        if item, ok := ResponsesSearchItem(tr); ok {
+            resp.Output = append(resp.Output, item)
+        }
+    }
+    resp.Output = append(resp.Output, ResponsesMessageItem("msg_"+suffix, strings.Join(sider.TextParts, ""), "completed"))
+    resp.Status = "completed"
+    resp.Usage = ResponsesUsage(ConvertSiderToAnthropic(sider, resp.Model).Usage, reasoning)
+}
+
+// ResponsesItemSuffix derives output item ids from the response id, which may be long.
+func ResponsesItemSuffix(responseID string) string {
+    s := strings.TrimPrefix(responseID, "resp_")
+    if len(s) > 24 {
+        s = s[len(s)-24:]
+    }
+    return s
+}
+
+// ResponsesMessageItem is the assistant message output item.
+func ResponsesMessageItem(id, text, status string) types.ResponsesOutputItem {
+    return types.ResponsesOutputItem{
+        ID:      id,
+        Type:    "message",
+        Status:  status,
+        Role:    "assistant",
+        Content: []types.ResponsesOutputText{{Type: "output_text", Text: text, Annotations: []any{}}},
+    }
+}
+
+// ResponsesReasoningItem reports Sider's reasoning as a reasoning summary.
+func ResponsesReasoningItem(id, text string) types.ResponsesOutputItem {
+    return types.ResponsesOutputItem{ID: id, Type: "reasoning", Summary: []types.ResponsesSummaryText{{Type: "summary_text", Text: text}}}
+}
+
+// ResponsesSearchItem maps a Sider search tool call to a web_search_call item.
+func ResponsesSearchItem(tr types.SiderToolResult) (types.ResponsesOutputItem, bool) {
+    if tr.ToolName != "search" && tr.ToolName != "web_search" {
+        return types.ResponsesOutputItem{}, false
+    }
+    var result struct {
+        Query   string `json:"query"`
+        Results []struct {
+            Title string `json:"title"`
+            URL   string `json:"url"`
+        } `json:"results"`
+    }
+    raw, _ := json.Marshal(tr.Result)
+    json.Unmarshal(raw, &result)
+
+    action := &types.ResponsesSearchAction{Type: "search", Query: result.Query}
+    for _, r := range result.Results {
+        if r.URL != "" {
+            action.Sources = append(action.Sources, types.ResponsesSearchSource{Type: "url", URL: r.URL, Title: r.Title})
+        }
+    }
+    status := "completed"
+    if tr.Error != "" {
+        status = "failed"
+    }
+    return types.ResponsesOutputItem{ID: "ws_" + tr.ToolID, Type: "web_search_call", Status: status, Action: action}, true
+}
+
+// ResponsesUsage converts the estimated usage, reporting the reasoning share.
+func ResponsesUsage(u types.AnthropicUsage, reasoning string) *types.ResponsesUsage {
+    return &types.ResponsesUsage{
+        InputTokens:         u.InputTokens,
+        OutputTokens:        u.OutputTokens,
+        OutputTokensDetails: types.ResponsesOutputTokensInfo{ReasoningTokens: (len(reasoning) + 3) / 4},
+        TotalTokens:         u.InputTokens + u.OutputTokens,
+    }
+}
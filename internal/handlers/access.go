@@ -0,0 +1,58 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/apikeys"
+    "sider2api/internal/session"
+    "sider2api/internal/siderclient"
+)
+
+// apiKeyFrom returns the proxy API key that authenticated the request, if any.
+func apiKeyFrom(c *gin.Context) (apikeys.Key, bool) {
+    v, ok := c.Get("apiKey")
+    if !ok {
+        return apikeys.Key{}, false
+    }
+    key, ok := v.(apikeys.Key)
+    return key, ok
+}
+
+// checkModelAccess rejects models outside the calling key's allow-list.
+func checkModelAccess(c *gin.Context, model string) error {
+    key, ok := apiKeyFrom(c)
+    if !ok || key.AllowsModel(model) {
+        return nil
+    }
+    return fmt.Errorf("%w: %s", apikeys.ErrModelNotAllowed, model)
+}
+
+// sessionOwner identifies the caller that owns the sessions it creates: its proxy key,
+// else its own Sider token. Callers served from the pool without a key share "".
+func sessionOwner(c *gin.Context) string {
+    if key, ok := apiKeyFrom(c); ok {
+        return "key:" + key.ID
+    }
+    return session.OwnerKey(c.GetString("authToken"))
+}
+
+// upstreamContext carries the caller's session owner, header profile and time zone to
+// the Sider client. A key's profile applies to its own Sider token; pooled tokens use
+// their pool entry's.
+func (h *Handler) upstreamContext(c *gin.Context) context.Context {
+    ctx := siderclient.WithSessionOwner(c.Request.Context(), sessionOwner(c))
+    if key, ok := apiKeyFrom(c); ok && key.Profile != "" {
+        ctx = siderclient.WithHeaderProfile(ctx, key.Profile)
+    }
+    if tz := c.GetHeader("X-Time-Zone"); tz != "" {
+        if siderclient.ValidTimeZone(tz) {
+            ctx = siderclient.WithTimeZone(ctx, tz)
+        } else {
+            h.Logger.Debug("ignoring invalid X-Time-Zone", "value", tz)
+        }
+    }
+    return ctx
+}
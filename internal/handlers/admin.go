@@ -0,0 +1,136 @@
+package handlers
+
+import (
+    "net/http"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/usage"
+)
+
+// adminSessionSummary is the list view of one session.
+type adminSessionSummary struct {
+    CID          string    `json:"cid"`
+    Model        string    `json:"model"`
+    MessageCount int       `json:"message_count"`
+    LastActivity time.Time `json:"last_activity"`
+    InFlight     bool      `json:"in_flight"`
+}
+
+// AdminListSessions handles GET /admin/sessions
+func (h *Handler) AdminListSessions(c *gin.Context) {
+    sessions := h.Sessions.Stats()
+    limits := h.Sessions.Limits()
+    sort.Slice(sessions, func(i, j int) bool {
+        return sessions[i].LastActivity.After(sessions[j].LastActivity)
+    })
+    out := make([]adminSessionSummary, 0, len(sessions))
+    for _, s := range sessions {
+        out = append(out, adminSessionSummary{
+            CID:          s.CID,
+            Model:        s.Model,
+            MessageCount: s.MessageCount,
+            LastActivity: s.LastActivity,
+            InFlight:     h.Sessions.InFlight(s.CID),
+        })
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "count":                  len(sessions),
+        "max_sessions":           limits.MaxSessions,
+        "max_sessions_per_owner": limits.MaxSessionsPerOwner,
+        "evictions":              limits.Evictions,
+        "sessions":               out,
+    })
+}
+
+// AdminGetSession handles GET /admin/sessions/:cid
+func (h *Handler) AdminGetSession(c *gin.Context) {
+    cid := c.Param("cid")
+    s, ok := h.Sessions.Snapshot(cid)
+    if !ok {
+        adminNotFound(c, cid)
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "session":   s,
+        "in_flight": h.Sessions.InFlight(cid),
+    })
+}
+
+// AdminDeleteSession handles DELETE /admin/sessions/:cid
+func (h *Handler) AdminDeleteSession(c *gin.Context) {
+    cid := c.Param("cid")
+    if !h.Sessions.Delete(cid) {
+        adminNotFound(c, cid)
+        return
+    }
+    h.Logger.Info("admin deleted session", "cid", cid)
+    c.Status(http.StatusNoContent)
+}
+
+// AdminCleanupSessions handles POST /admin/sessions/cleanup
+func (h *Handler) AdminCleanupSessions(c *gin.Context) {
+    removed := h.Sessions.Cleanup()
+    h.Logger.Info("admin forced session cleanup", "removed", removed)
+    c.JSON(http.StatusOK, gin.H{"removed": removed, "remaining": len(h.Sessions.Stats())})
+}
+
+func adminNotFound(c *gin.Context, cid string) {
+    c.JSON(http.StatusNotFound, gin.H{"error": gin.H{"type": "not_found_error", "message": "session not found: " + cid}})
+}
+
+// AdminListTokens handles GET /admin/tokens
+func (h *Handler) AdminListTokens(c *gin.Context) {
+    pool := h.Client.Pool
+    if pool == nil {
+        c.JSON(http.StatusOK, gin.H{"enabled": false, "tokens": []any{}})
+        return
+    }
+    tokens := pool.Health()
+    healthy := 0
+    for _, t := range tokens {
+        if t.Healthy {
+            healthy++
+        }
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "enabled":  true,
+        "strategy": pool.Strategy(),
+        "healthy":  healthy,
+        "total":    len(tokens),
+        "tokens":   tokens,
+    })
+}
+
+// AdminUsage handles GET /admin/usage?key=&from=&to=
+func (h *Handler) AdminUsage(c *gin.Context) {
+    from, err := usage.ParseTime(c.Query("from"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"type": "invalid_request_error", "message": err.Error()}})
+        return
+    }
+    to, err := usage.ParseTime(c.Query("to"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": gin.H{"type": "invalid_request_error", "message": err.Error()}})
+        return
+    }
+    // a bare end date includes that whole day
+    if len(c.Query("to")) == len(time.DateOnly) {
+        to = to.AddDate(0, 0, 1)
+    }
+    // key accepts a proxy key id as well as a full client id (token:…, ip:…)
+    client := c.Query("key")
+    if client != "" && !strings.Contains(client, ":") {
+        client = "key:" + client
+    }
+
+    report, err := h.Usage.Report(usage.Filter{Client: client, From: from, To: to})
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"type": "api_error", "message": err.Error()}})
+        return
+    }
+    c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/converter"
+    "sider2api/pkg/types"
+)
+
+const defaultHistoryLimit = 100
+
+// GetConversationMessages handles GET /v1/sider/conversations/:cid/messages. It fetches the
+// upstream transcript, returns it as Anthropic messages and rebuilds the session so the next
+// /v1/messages call with this CID threads onto the latest assistant reply.
+func (h *Handler) GetConversationMessages(c *gin.Context) {
+    authToken, ok := c.Get("authToken")
+    if !ok {
+        c.JSON(http.StatusUnauthorized, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "authentication_error", Message: "Authentication required"}})
+        return
+    }
+    tokenStr, _ := authToken.(string)
+
+    cid := c.Param("cid")
+    limit := defaultHistoryLimit
+    if v := c.Query("limit"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n <= 0 {
+            c.JSON(http.StatusBadRequest, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "invalid_request_error", Message: "limit must be a positive integer"}})
+            return
+        }
+        limit = n
+    }
+
+    release, err := h.Sessions.Acquire(c.Request.Context(), cid)
+    if err != nil {
+        h.writeAnthropicError(c, err, false)
+        return
+    }
+    defer release()
+
+    history, err := h.Client.FetchConversationHistory(h.upstreamContext(c), cid, tokenStr, limit)
+    if err != nil {
+        h.writeAnthropicError(c, err, false)
+        return
+    }
+
+    thread := converter.ConvertSiderHistoryToAnthropic(history.Data.Messages)
+    model := c.Query("model")
+    if model == "" {
+        model = thread.Model
+    }
+
+    resp := gin.H{
+        "cid":               cid,
+        "messages":          thread.Messages,
+        "parent_message_id": thread.AssistantMessageID,
+    }
+    if thread.AssistantMessageID != "" {
+        state := h.Sessions.Import(sessionOwner(c), cid, thread.UserMessageID, thread.AssistantMessageID, model, len(thread.Messages))
+        resp["session"] = state
+        c.Header("X-Conversation-ID", cid)
+        c.Header("X-Assistant-Message-ID", thread.AssistantMessageID)
+    }
+    c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/apikeys"
+    "sider2api/internal/session"
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// errorMapping is how one failure is presented on the Anthropic and OpenAI surfaces.
+// The statuses differ where a surface has its own convention, e.g. Anthropic's 529.
+type errorMapping struct {
+    AnthropicStatus int
+    OpenAIStatus    int
+    AnthropicType   string
+    OpenAIType    string
+    OpenAICode    string
+}
+
+// mapError maps queue and upstream failures to HTTP status and error types.
+func mapError(err error) errorMapping {
+    switch {
+    case errors.Is(err, session.ErrConversationBusy):
+        return errorMapping{http.StatusConflict, http.StatusConflict, "invalid_request_error", "invalid_request_error", "conversation_busy"}
+    case errors.Is(err, session.ErrConversationWaitTimeout):
+        return errorMapping{http.StatusTooManyRequests, http.StatusTooManyRequests, "rate_limit_error", "rate_limit_error", "conversation_wait_timeout"}
+    case errors.Is(err, session.ErrResponseNotFound):
+        return errorMapping{http.StatusNotFound, http.StatusNotFound, "not_found_error", "invalid_request_error", "previous_response_not_found"}
+    case errors.Is(err, apikeys.ErrModelNotAllowed):
+        return errorMapping{http.StatusForbidden, http.StatusForbidden, "permission_error", "invalid_request_error", "model_not_allowed"}
+    }
+
+    switch siderclient.KindOf(err) {
+    case siderclient.KindAuth:
+        return errorMapping{http.StatusUnauthorized, http.StatusUnauthorized, "authentication_error", "invalid_request_error", "invalid_api_key"}
+    case siderclient.KindRateLimit:
+        return errorMapping{http.StatusTooManyRequests, http.StatusTooManyRequests, "rate_limit_error", "rate_limit_error", "rate_limit_exceeded"}
+    case siderclient.KindQuotaExhausted:
+        return errorMapping{http.StatusTooManyRequests, http.StatusTooManyRequests, "rate_limit_error", "insufficient_quota", "insufficient_quota"}
+    case siderclient.KindModelUnavailable:
+        return errorMapping{http.StatusNotFound, http.StatusNotFound, "not_found_error", "invalid_request_error", "model_not_found"}
+    case siderclient.KindTimeout:
+        return errorMapping{http.StatusGatewayTimeout, http.StatusGatewayTimeout, "overloaded_error", "server_error", "timeout"}
+    case siderclient.KindCircuitOpen:
+        return errorMapping{http.StatusServiceUnavailable, http.StatusServiceUnavailable, "overloaded_error", "server_error", "circuit_open"}
+    case siderclient.KindUnavailable:
+        return errorMapping{529, http.StatusServiceUnavailable, "overloaded_error", "server_error", "upstream_unavailable"}
+    case siderclient.KindBadRequest:
+        return errorMapping{http.StatusBadRequest, http.StatusBadRequest, "invalid_request_error", "invalid_request_error", "upstream_bad_request"}
+    case siderclient.KindStream, siderclient.KindIncomplete:
+        return errorMapping{http.StatusBadGateway, http.StatusBadGateway, "api_error", "server_error", "upstream_error"}
+    }
+
+    if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+        return errorMapping{http.StatusRequestTimeout, http.StatusRequestTimeout, "api_error", "server_error", "request_canceled"}
+    }
+    return errorMapping{http.StatusInternalServerError, http.StatusInternalServerError, "api_error", "server_error", ""}
+}
+
+// isInStreamError reports whether err arrived as an error event inside the upstream stream.
+func isInStreamError(err error) bool {
+    var ue *siderclient.UpstreamError
+    return errors.As(err, &ue) && ue.InStream
+}
+
+// writeAnthropicError writes err as an Anthropic error response, or as an SSE error
+// event when the client asked for a stream and the failure happened mid-stream or
+// after events were already sent.
+func (h *Handler) writeAnthropicError(c *gin.Context, err error, stream bool) {
+    setRetryHeader(c, siderclient.AttemptsOf(err))
+    h.setCircuitRetryAfter(c, err)
+    m := mapError(err)
+    h.Logger.Warn("request failed", "path", c.Request.URL.Path, "status", m.AnthropicStatus, "type", m.AnthropicType, "error", err)
+    body := types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: m.AnthropicType, Message: err.Error()}}
+    if stream && (isInStreamError(err) || c.Writer.Written()) {
+        w := startSSE(c, nil)
+        data, _ := json.Marshal(body)
+        w.Write([]byte("event: error\ndata: "))
+        w.Write(data)
+        w.Write([]byte("\n\n"))
+        w.Flush()
+        return
+    }
+    c.JSON(m.AnthropicStatus, body)
+}
+
+// writeOpenAIError is the OpenAI-format counterpart of writeAnthropicError.
+func (h *Handler) writeOpenAIError(c *gin.Context, err error, stream bool) {
+    setRetryHeader(c, siderclient.AttemptsOf(err))
+    h.setCircuitRetryAfter(c, err)
+    m := mapError(err)
+    h.Logger.Warn("request failed", "path", c.Request.URL.Path, "status", m.OpenAIStatus, "type", m.OpenAIType, "error", err)
+    body := types.OpenAIErrorResponse{Error: types.OpenAIError{Message: err.Error(), Type: m.OpenAIType, Code: m.OpenAICode}}
+    if stream && (isInStreamError(err) || c.Writer.Written()) {
+        w := startSSE(c, nil)
+        data, _ := json.Marshal(body)
+        w.Write([]byte("data: "))
+        w.Write(data)
+        w.Write([]byte("\n\ndata: [DONE]\n\n"))
+        w.Flush()
+        return
+    }
+    c.JSON(m.OpenAIStatus, body)
+}
+
+// startSSE sets event-stream headers (plus extra) and returns the response writer.
+func startSSE(c *gin.Context, extra map[string]string) gin.ResponseWriter {
+    w := c.Writer
+    for k, v := range extra {
+        w.Header().Set(k, v)
+    }
+    w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    return w
+}
+
+// setCircuitRetryAfter tells clients when the open breaker will next admit a probe.
+func (h *Handler) setCircuitRetryAfter(c *gin.Context, err error) {
+    if siderclient.KindOf(err) != siderclient.KindCircuitOpen || h.Client == nil || h.Client.Breaker == nil {
+        return
+    }
+    retryAt := h.Client.Breaker.Status().RetryAt
+    if retryAt.IsZero() {
+        return
+    }
+    secs := int(time.Until(retryAt).Seconds()) + 1
+    if secs < 1 {
+        secs = 1
+    }
+    c.Header("Retry-After", strconv.Itoa(secs))
+}
+
+// setRetryHeader reports how many upstream retries a request needed.
+func setRetryHeader(c *gin.Context, attempts int) {
+    if attempts < 1 {
+        return
+    }
+    c.Header("X-Sider2api-Retries", strconv.Itoa(attempts-1))
+}
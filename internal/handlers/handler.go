@@ -4,18 +4,29 @@ import (
     "log/slog"
 
     "sider2api/internal/config"
+    "sider2api/internal/models"
+    "sider2api/internal/provider"
     "sider2api/internal/session"
     "sider2api/internal/siderclient"
+    "sider2api/internal/usage"
 )
 
 // Handler aggregates dependencies used by HTTP handlers.
 type Handler struct {
     Config   config.Config
     Client   *siderclient.Client
+    // Models is the model registry; nil uses the built-in one.
+    Models *models.Registry
+    // Discovery, when set, adds the models Sider currently lists to GET /v1/models.
+    Discovery *models.Discovery
+    // Providers picks the upstream for each model; Sider unless configured otherwise.
+    Providers *provider.Router
     Sessions *session.SiderSessionManager
     Logger   *slog.Logger
+    // Usage is the request ledger behind the admin usage report.
+    Usage *usage.Ledger
 }
 
 func New(cfg config.Config, client *siderclient.Client, sessions *session.SiderSessionManager, logger *slog.Logger) *Handler {
-    return &Handler{Config: cfg, Client: client, Sessions: sessions, Logger: logger}
+    return &Handler{Config: cfg, Client: client, Sessions: sessions, Logger: logger, Providers: provider.NewRouter(&provider.Sider{Client: client})}
 }
@@ -6,17 +6,39 @@ import (
 
     "github.com/gin-gonic/gin"
 
+    "sider2api/internal/siderclient"
     "sider2api/pkg/types"
 )
 
 func (h *Handler) Health(c *gin.Context) {
-    c.JSON(http.StatusOK, types.HealthResponse{
+    resp := types.HealthResponse{
         Status:    "ok",
         Service:   "sider2api",
         Version:   "1.0.0-go",
         Timestamp: time.Now().UTC().Format(time.RFC3339),
         TechStack: "gin + go",
-    })
+    }
+    if h.Client != nil && h.Client.Breaker != nil {
+        st := h.Client.Breaker.Status()
+        up := &types.UpstreamHealth{
+            Circuit:   st.State.String(),
+            Requests:  st.Requests,
+            ErrorRate: st.ErrorRate,
+            SlowRate:  st.SlowRate,
+            LastError: st.LastError,
+        }
+        if !st.OpenedAt.IsZero() {
+            up.OpenedAt = st.OpenedAt.UTC().Format(time.RFC3339)
+        }
+        if !st.RetryAt.IsZero() {
+            up.RetryAt = st.RetryAt.UTC().Format(time.RFC3339)
+        }
+        if st.State != siderclient.BreakerClosed {
+            resp.Status = "degraded"
+        }
+        resp.Upstream = up
+    }
+    c.JSON(http.StatusOK, resp)
 }
 
 func (h *Handler) Root(c *gin.Context) {
@@ -30,7 +52,10 @@ func (h *Handler) Root(c *gin.Context) {
             "messages":         "/v1/messages",
             "count_tokens":     "/v1/messages/count_tokens",
             "chat_completions": "/v1/chat/completions",
+            "responses":        "/v1/responses",
+            "conversation":     "/v1/sider/conversations/{cid}/messages",
             "playground":       "/ui",
+            "admin_sessions":   "/admin/sessions",
         },
     })
 }
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+    "context"
     "encoding/json"
     "net/http"
     "strings"
@@ -8,6 +9,7 @@ import (
     "github.com/gin-gonic/gin"
 
     "sider2api/internal/converter"
+    "sider2api/internal/provider"
     "sider2api/pkg/types"
 )
 
@@ -25,15 +27,31 @@ func (h *Handler) PostMessages(c *gin.Context) {
         c.JSON(http.StatusBadRequest, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "invalid_request_error", Message: err.Error()}})
         return
     }
+    if err := checkModelAccess(c, req.Model); err != nil {
+        h.writeAnthropicError(c, err, false)
+        return
+    }
 
-    conversationID := c.Query("cid")
-    if conversationID == "" {
-        conversationID = c.GetHeader("X-Conversation-ID")
+    p := h.Providers.For(req.Model)
+    conversationID := ""
+    // stateless providers get the whole history with every turn
+    if p.Capabilities(req.Model).Conversations {
+        conversationID = c.Query("cid")
+        if conversationID == "" {
+            conversationID = c.GetHeader("X-Conversation-ID")
+        }
+        if conversationID == "" && hasAssistantHistory(req.Messages) {
+            conversationID = h.Config.ContinuousCID
+        }
     }
 
-    if conversationID == "" && hasAssistantHistory(req.Messages) {
-        conversationID = h.Config.ContinuousCID
+    // serialize turns on the same conversation so each one threads onto the previous reply
+    release, err := h.Sessions.Acquire(c.Request.Context(), conversationID)
+    if err != nil {
+        h.writeAnthropicError(c, err, false)
+        return
     }
+    defer release()
 
     parentMessageID := c.GetHeader("X-Parent-Message-ID")
     if parentMessageID == "" && conversationID != "" {
@@ -44,26 +62,34 @@ func (h *Handler) PostMessages(c *gin.Context) {
         ConversationID:  conversationID,
         ParentMessageID: parentMessageID,
         ContinuousCID:   h.Config.ContinuousCID,
+        Models:          h.Models,
+        CapabilityModel: capabilityModel(p, req.Model),
     })
     if err != nil {
         c.JSON(http.StatusBadRequest, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "invalid_request_error", Message: err.Error()}})
         return
     }
+    setWarningsHeader(c, siderReq.Warnings)
 
-    ctx := c.Request.Context()
-    siderResp, err := h.Client.Chat(ctx, siderReq, tokenStr)
+    ctx := h.upstreamContext(c)
+    preq := provider.Request{Anthropic: req, Sider: siderReq, Token: tokenStr}
+    if req.Stream {
+        h.streamMessages(c, ctx, p, preq)
+        return
+    }
+    siderResp, err := p.Chat(ctx, preq)
+    setRetryHeader(c, siderResp.Attempts)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "api_error", Message: err.Error()}})
+        if h.clientGone(c, siderResp, types.AnthropicUsage{}) {
+            return
+        }
+        h.writeAnthropicError(c, err, false)
         return
     }
 
-    anthResp := converter.ConvertSiderToAnthropic(siderResp, req.Model)
+    anthResp := converter.ConvertSiderToAnthropic(siderResp, answeredModel(c, p, req.Model, siderResp))
     headers := converter.SessionHeadersFromSider(siderResp)
-
-    if req.Stream {
-        h.writeAnthropicStream(c, anthResp, headers)
-        return
-    }
+    c.Set("usage", anthResp.Usage)
 
     for k, v := range headers {
         c.Header(k, v)
@@ -83,6 +109,42 @@ func (h *Handler) CountTokens(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{"input_tokens": est})
 }
 
+// upstreamModelHeader reports the model that actually produced the reply.
+const upstreamModelHeader = "X-Sider2api-Upstream-Model"
+
+// answeredModel sets the upstream model header from resp, records it on the context
+// for the usage ledger and returns the model to report in the response: the
+// requested one, or for a routed alias the target that answered.
+func answeredModel(c *gin.Context, p provider.Provider, requested string, resp types.SiderParsedResponse) string {
+    if resp.Model == "" {
+        return requested
+    }
+    c.Header(upstreamModelHeader, resp.Model)
+    c.Set("upstreamModel", resp.Model)
+    if _, routed := p.(*provider.Route); routed {
+        return resp.Model
+    }
+    return requested
+}
+
+// capabilityModel is the model a request is checked against: the requested one, or
+// for a routed alias its first target, whose capabilities the route reports.
+func capabilityModel(p provider.Provider, requested string) string {
+    if r, ok := p.(*provider.Route); ok && len(r.Targets) > 0 {
+        return r.Targets[0].Model
+    }
+    return requested
+}
+
+// warningsHeader lists the changes made to fit a request to the model's capabilities.
+const warningsHeader = "X-Sider2api-Warnings"
+
+func setWarningsHeader(c *gin.Context, warnings []string) {
+    if len(warnings) > 0 {
+        c.Header(warningsHeader, strings.Join(warnings, "; "))
+    }
+}
+
 func hasAssistantHistory(messages []types.AnthropicMessage) bool {
     for _, m := range messages {
         if m.Role == "assistant" {
@@ -92,15 +154,66 @@ func hasAssistantHistory(messages []types.AnthropicMessage) bool {
     return false
 }
 
+// streamMessages relays the upstream stream as Anthropic SSE events while it is
+// generated. A client disconnect cancels ctx, which aborts the upstream request.
+func (h *Handler) streamMessages(c *gin.Context, ctx context.Context, p provider.Provider, preq provider.Request) {
+    model := preq.Anthropic.Model
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    id := converter.NewResponseID()
+    // Sider reports no usage, so the live message_start carries the request estimate
+    inputTokens := converter.EstimateInputTokens(preq.Anthropic)
+    var send func(payload any) error
+    live := &liveStream{
+        c:      c,
+        cancel: cancel,
+        open: func(partial types.SiderParsedResponse) error {
+            answered := answeredModel(c, p, model, partial)
+            send = sseSender(startSSE(c, converter.SessionHeadersFromSider(partial)))
+            if err := send(gin.H{"type": "message_start", "message": gin.H{"id": id, "type": "message", "role": "assistant", "content": []any{}, "model": answered, "stop_reason": nil, "usage": gin.H{"input_tokens": inputTokens, "output_tokens": 0}}}); err != nil {
+                return err
+            }
+            return send(gin.H{"type": "content_block_start", "index": 0, "content_block": gin.H{"type": "text", "text": ""}})
+        },
+    }
+    live.emit = func(text string) error {
+        return send(gin.H{"type": "content_block_delta", "index": 0, "delta": gin.H{"type": "text_delta", "text": text}})
+    }
+
+    siderResp, err := p.ChatStream(ctx, preq, live.callback)
+    anthResp := converter.ConvertSiderToAnthropic(siderResp, model)
+    anthResp.Usage.InputTokens = inputTokens
+    if !live.started {
+        setRetryHeader(c, siderResp.Attempts)
+    }
+    if h.clientGone(c, siderResp, anthResp.Usage) {
+        return
+    }
+    if err != nil {
+        h.writeAnthropicError(c, err, true)
+        return
+    }
+    c.Set("usage", anthResp.Usage)
+
+    if !live.started {
+        // nothing was streamed (e.g. an empty reply); send the buffered form
+        anthResp.ID = id
+        anthResp.Model = answeredModel(c, p, model, siderResp)
+        h.writeAnthropicStream(c, anthResp, converter.SessionHeadersFromSider(siderResp))
+        return
+    }
+    if tail := live.closeThink(); tail != "" {
+        live.emit(tail)
+    }
+    send(gin.H{"type": "content_block_stop", "index": 0})
+    send(gin.H{"type": "message_delta", "delta": gin.H{"stop_reason": "end_turn"}, "usage": gin.H{"output_tokens": anthResp.Usage.OutputTokens}})
+    send(gin.H{"type": "message_stop"})
+}
+
 // writeAnthropicStream emits SSE following Anthropic-like structure.
 func (h *Handler) writeAnthropicStream(c *gin.Context, resp types.AnthropicResponse, headers map[string]string) {
-    w := c.Writer
-    for k, v := range headers {
-        w.Header().Set(k, v)
-    }
-    w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
-    w.Header().Set("Cache-Control", "no-cache")
-    w.Header().Set("Connection", "keep-alive")
+    w := startSSE(c, headers)
     flusher, ok := w.(http.Flusher)
     if !ok {
         c.AbortWithStatus(http.StatusInternalServerError)
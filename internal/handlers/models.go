@@ -0,0 +1,119 @@
+package handlers
+
+import (
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/models"
+    "sider2api/pkg/types"
+)
+
+// registryEpoch stands in for model creation times, which the registry does not track.
+const registryEpoch = "1970-01-01T00:00:00Z"
+
+// ListModels handles GET /v1/models. Anthropic SDKs send anthropic-version and get
+// the Anthropic format; everyone else gets the OpenAI format. Models outside the
+// calling key's allow-list are left out; the registry default is flagged.
+func (h *Handler) ListModels(c *gin.Context) {
+    list, def := h.visibleModels(c)
+    if isAnthropicClient(c) {
+        resp := types.AnthropicModelList{Data: []types.AnthropicModel{}}
+        for _, m := range list {
+            resp.Data = append(resp.Data, anthropicModel(m, m.ID == def))
+        }
+        if len(list) > 0 {
+            resp.FirstID = list[0].ID
+            resp.LastID = list[len(list)-1].ID
+        }
+        c.JSON(http.StatusOK, resp)
+        return
+    }
+    resp := types.OpenAIModelList{Object: "list", Data: []types.OpenAIModel{}}
+    for _, m := range list {
+        resp.Data = append(resp.Data, openAIModel(m, m.ID == def))
+    }
+    c.JSON(http.StatusOK, resp)
+}
+
+// GetModel handles GET /v1/models/:id; aliases resolve to their model.
+func (h *Handler) GetModel(c *gin.Context) {
+    id := c.Param("id")
+    list, def := h.visibleModels(c)
+    for _, m := range list {
+        if strings.EqualFold(m.ID, id) || containsFold(m.Aliases, id) {
+            if isAnthropicClient(c) {
+                c.JSON(http.StatusOK, anthropicModel(m, m.ID == def))
+            } else {
+                c.JSON(http.StatusOK, openAIModel(m, m.ID == def))
+            }
+            return
+        }
+    }
+    msg := "model not found: " + id
+    if isAnthropicClient(c) {
+        c.JSON(http.StatusNotFound, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "not_found_error", Message: msg}})
+        return
+    }
+    c.JSON(http.StatusNotFound, types.OpenAIErrorResponse{Error: types.OpenAIError{Message: msg, Type: "invalid_request_error", Code: "model_not_found"}})
+}
+
+// visibleModels is the registry (with any discovered models) followed by the models
+// and aliases configured for other providers, restricted to what the caller may use,
+// along with the ID of the registry default.
+func (h *Handler) visibleModels(c *gin.Context) ([]models.Model, string) {
+    registry := h.Models
+    if h.Discovery != nil {
+        registry = h.Discovery.Registry(c.Request.Context())
+    }
+    list := registry.Models()
+    def := registry.Default().ID
+    if h.Providers != nil {
+        for _, name := range h.Providers.Names() {
+            if _, known := registry.Lookup(name); !known {
+                list = append(list, models.Model{ID: name, DisplayName: name, OwnedBy: h.Providers.For(name).Name()})
+            }
+        }
+    }
+    key, ok := apiKeyFrom(c)
+    if !ok {
+        return list, def
+    }
+    out := list[:0]
+    for _, m := range list {
+        if key.AllowsModel(m.ID) {
+            out = append(out, m)
+        }
+    }
+    return out, def
+}
+
+func isAnthropicClient(c *gin.Context) bool {
+    return c.GetHeader("anthropic-version") != ""
+}
+
+func capabilities(m models.Model) *types.ModelCapabilities {
+    return &types.ModelCapabilities{Think: m.SupportsThink, Search: m.SupportsSearch, Vision: m.SupportsVision, Images: m.SupportsImages}
+}
+
+func openAIModel(m models.Model, isDefault bool) types.OpenAIModel {
+    owner := m.OwnedBy
+    if owner == "" {
+        owner = "sider"
+    }
+    return types.OpenAIModel{ID: m.ID, Object: "model", OwnedBy: owner, DisplayName: m.DisplayName, ContextWindow: m.ContextWindow, Capabilities: capabilities(m), Default: isDefault}
+}
+
+func anthropicModel(m models.Model, isDefault bool) types.AnthropicModel {
+    return types.AnthropicModel{Type: "model", ID: m.ID, DisplayName: m.DisplayName, CreatedAt: registryEpoch, ContextWindow: m.ContextWindow, Capabilities: capabilities(m), Default: isDefault}
+}
+
+func containsFold(list []string, s string) bool {
+    for _, v := range list {
+        if strings.EqualFold(v, s) {
+            return true
+        }
+    }
+    return false
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
+    "context"
     "encoding/json"
     "net/http"
+    "strings"
     "time"
 
     "github.com/gin-gonic/gin"
 
     "sider2api/internal/converter"
+    "sider2api/internal/provider"
     "sider2api/pkg/types"
 )
 
@@ -25,16 +28,31 @@ func (h *Handler) PostChatCompletions(c *gin.Context) {
         c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(err.Error(), "invalid_request_error"))
         return
     }
+    if err := checkModelAccess(c, req.Model); err != nil {
+        h.writeOpenAIError(c, err, false)
+        return
+    }
 
     anthropicReq := converter.OpenAIToAnthropic(req)
 
-    conversationID := c.Query("cid")
-    if conversationID == "" {
-        conversationID = c.GetHeader("X-Conversation-ID")
+    p := h.Providers.For(anthropicReq.Model)
+    conversationID := ""
+    if p.Capabilities(anthropicReq.Model).Conversations {
+        conversationID = c.Query("cid")
+        if conversationID == "" {
+            conversationID = c.GetHeader("X-Conversation-ID")
+        }
+        if conversationID == "" && hasAssistantHistory(anthropicReq.Messages) {
+            conversationID = h.Config.ContinuousCID
+        }
     }
-    if conversationID == "" && hasAssistantHistory(anthropicReq.Messages) {
-        conversationID = h.Config.ContinuousCID
+
+    release, err := h.Sessions.Acquire(c.Request.Context(), conversationID)
+    if err != nil {
+        h.writeOpenAIError(c, err, false)
+        return
     }
+    defer release()
 
     parentMessageID := c.GetHeader("X-Parent-Message-ID")
     if parentMessageID == "" && conversationID != "" {
@@ -45,27 +63,36 @@ func (h *Handler) PostChatCompletions(c *gin.Context) {
         ConversationID:  conversationID,
         ParentMessageID: parentMessageID,
         ContinuousCID:   h.Config.ContinuousCID,
+        Models:          h.Models,
+        CapabilityModel: capabilityModel(p, anthropicReq.Model),
     })
     if err != nil {
         c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(err.Error(), "invalid_request_error"))
         return
     }
+    setWarningsHeader(c, siderReq.Warnings)
 
-    ctx := c.Request.Context()
-    siderResp, err := h.Client.Chat(ctx, siderReq, tokenStr)
+    ctx := h.upstreamContext(c)
+    preq := provider.Request{Anthropic: anthropicReq, Sider: siderReq, Token: tokenStr}
+    if req.Stream {
+        h.streamChatCompletions(c, ctx, p, preq, req)
+        return
+    }
+    siderResp, err := p.Chat(ctx, preq)
+    setRetryHeader(c, siderResp.Attempts)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, converter.CreateOpenAIErrorResponse(err.Error(), "api_error"))
+        if h.clientGone(c, siderResp, types.AnthropicUsage{}) {
+            return
+        }
+        h.writeOpenAIError(c, err, false)
         return
     }
 
     anthropicResp := converter.ConvertSiderToAnthropic(siderResp, anthropicReq.Model)
     openaiResp := converter.AnthropicToOpenAIResponse(anthropicResp, req)
+    openaiResp.Model = answeredModel(c, p, req.Model, siderResp)
     headers := converter.SessionHeadersFromSider(siderResp)
-
-    if req.Stream {
-        h.writeOpenAIStream(c, openaiResp, headers)
-        return
-    }
+    c.Set("usage", anthropicResp.Usage)
 
     for k, v := range headers {
         c.Header(k, v)
@@ -73,14 +100,66 @@ func (h *Handler) PostChatCompletions(c *gin.Context) {
     c.JSON(http.StatusOK, openaiResp)
 }
 
-func (h *Handler) writeOpenAIStream(c *gin.Context, resp types.OpenAIChatCompletionResponse, headers map[string]string) {
-    w := c.Writer
-    for k, v := range headers {
-        w.Header().Set(k, v)
+// streamChatCompletions relays the upstream stream as chat.completion.chunk events while
+// it is generated. A client disconnect cancels ctx, which aborts the upstream request.
+func (h *Handler) streamChatCompletions(c *gin.Context, ctx context.Context, p provider.Provider, preq provider.Request, req types.OpenAIChatCompletionRequest) {
+    model := preq.Anthropic.Model
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    id := "chatcmpl-" + strings.TrimPrefix(converter.NewResponseID(), "msg_")
+    base := map[string]any{
+        "id":      id,
+        "object":  "chat.completion.chunk",
+        "created": time.Now().Unix(),
+        "model":   req.Model,
     }
-    w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
-    w.Header().Set("Cache-Control", "no-cache")
-    w.Header().Set("Connection", "keep-alive")
+    var send func(payload any) error
+    live := &liveStream{
+        c:      c,
+        cancel: cancel,
+        open: func(partial types.SiderParsedResponse) error {
+            base["model"] = answeredModel(c, p, req.Model, partial)
+            send = sseSender(startSSE(c, converter.SessionHeadersFromSider(partial)))
+            return send(merge(base, map[string]any{"choices": []map[string]any{{"index": 0, "delta": map[string]any{"role": "assistant"}, "finish_reason": nil}}}))
+        },
+    }
+    live.emit = func(text string) error {
+        return send(merge(base, map[string]any{"choices": []map[string]any{{"index": 0, "delta": map[string]any{"content": text}, "finish_reason": nil}}}))
+    }
+
+    siderResp, err := p.ChatStream(ctx, preq, live.callback)
+    anthropicResp := converter.ConvertSiderToAnthropic(siderResp, model)
+    if !live.started {
+        setRetryHeader(c, siderResp.Attempts)
+    }
+    if h.clientGone(c, siderResp, anthropicResp.Usage) {
+        return
+    }
+    if err != nil {
+        h.writeOpenAIError(c, err, true)
+        return
+    }
+    c.Set("usage", anthropicResp.Usage)
+
+    openaiResp := converter.AnthropicToOpenAIResponse(anthropicResp, req)
+    if !live.started {
+        // nothing was streamed (e.g. an empty reply); send the buffered form
+        openaiResp.ID = id
+        openaiResp.Model = answeredModel(c, p, req.Model, siderResp)
+        h.writeOpenAIStream(c, openaiResp, converter.SessionHeadersFromSider(siderResp))
+        return
+    }
+    if tail := live.closeThink(); tail != "" {
+        live.emit(tail)
+    }
+    send(merge(base, map[string]any{"choices": []map[string]any{{"index": 0, "delta": map[string]any{}, "finish_reason": "stop"}}, "usage": openaiResp.Usage}))
+    c.Writer.Write([]byte("data: [DONE]\n\n"))
+    c.Writer.Flush()
+}
+
+func (h *Handler) writeOpenAIStream(c *gin.Context, resp types.OpenAIChatCompletionResponse, headers map[string]string) {
+    w := startSSE(c, headers)
     flusher, ok := w.(http.Flusher)
     if !ok {
         c.AbortWithStatus(http.StatusInternalServerError)
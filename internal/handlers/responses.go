@@ -0,0 +1,332 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/converter"
+    "sider2api/internal/provider"
+    "sider2api/internal/session"
+    "sider2api/pkg/types"
+)
+
+// PostResponses handles /v1/responses (OpenAI Responses API). previous_response_id
+// continues the Sider conversation of that response, threaded onto its reply.
+func (h *Handler) PostResponses(c *gin.Context) {
+    authToken, ok := c.Get("authToken")
+    if !ok {
+        c.JSON(http.StatusUnauthorized, converter.CreateOpenAIErrorResponse("Authentication required", "authentication_error"))
+        return
+    }
+    tokenStr, _ := authToken.(string)
+
+    var req types.ResponsesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(err.Error(), "invalid_request_error"))
+        return
+    }
+    if err := checkModelAccess(c, req.Model); err != nil {
+        h.writeOpenAIError(c, err, false)
+        return
+    }
+
+    anthropicReq, err := converter.ResponsesToAnthropic(req)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(err.Error(), "invalid_request_error"))
+        return
+    }
+
+    p := h.Providers.For(anthropicReq.Model)
+    conversationID, parentMessageID := "", ""
+    if req.PreviousResponseID != "" {
+        if !p.Capabilities(anthropicReq.Model).Conversations {
+            c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(fmt.Sprintf("previous_response_id is not supported for %s; send the whole conversation as input", req.Model), "invalid_request_error"))
+            return
+        }
+        conversationID, parentMessageID, err = h.Sessions.ResolveResponse(sessionOwner(c), req.PreviousResponseID)
+        if err != nil {
+            h.writeOpenAIError(c, err, false)
+            return
+        }
+    }
+
+    release, err := h.Sessions.Acquire(c.Request.Context(), conversationID)
+    if err != nil {
+        h.writeOpenAIError(c, err, false)
+        return
+    }
+    defer release()
+
+    siderReq, err := converter.ConvertAnthropicToSider(anthropicReq, converter.ConvertOptions{
+        ConversationID:  conversationID,
+        ParentMessageID: parentMessageID,
+        ContinuousCID:   h.Config.ContinuousCID,
+        Models:          h.Models,
+        CapabilityModel: capabilityModel(p, anthropicReq.Model),
+    })
+    if err != nil {
+        c.JSON(http.StatusBadRequest, converter.CreateOpenAIErrorResponse(err.Error(), "invalid_request_error"))
+        return
+    }
+    setWarningsHeader(c, siderReq.Warnings)
+
+    ctx := h.upstreamContext(c)
+    preq := provider.Request{Anthropic: anthropicReq, Sider: siderReq, Token: tokenStr}
+    if req.Stream {
+        h.streamResponses(c, ctx, p, preq, req)
+        return
+    }
+    siderResp, err := p.Chat(ctx, preq)
+    setRetryHeader(c, siderResp.Attempts)
+    if err != nil {
+        if h.clientGone(c, siderResp, types.AnthropicUsage{}) {
+            return
+        }
+        h.writeOpenAIError(c, err, false)
+        return
+    }
+
+    resp := converter.NewResponsesObject(responseID(siderResp), answeredModel(c, p, req.Model, siderResp), req)
+    converter.CompleteResponsesObject(&resp, siderResp)
+    c.Set("usage", converter.ConvertSiderToAnthropic(siderResp, req.Model).Usage)
+
+    for k, v := range converter.SessionHeadersFromSider(siderResp) {
+        c.Header(k, v)
+    }
+    c.JSON(http.StatusOK, resp)
+}
+
+// responseID names a reply after its Sider conversation and message, so it can be
+// continued; replies without them (stateless providers) get a random id.
+func responseID(resp types.SiderParsedResponse) string {
+    if resp.ConversationID != "" && resp.MessageIDs != nil && resp.MessageIDs.Assistant != "" {
+        return session.ResponseID(resp.ConversationID, resp.MessageIDs.Assistant)
+    }
+    return "resp_" + strings.TrimPrefix(converter.NewResponseID(), "msg_")
+}
+
+// streamResponses relays the upstream stream as typed Responses API events. Like
+// streamChatCompletions it only starts the SSE response with the first reasoning,
+// search or text event, so earlier failures still get a normal error response.
+func (h *Handler) streamResponses(c *gin.Context, ctx context.Context, p provider.Provider, preq provider.Request, req types.ResponsesRequest) {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    rs := &responsesStream{c: c, cancel: cancel}
+    rs.open = func(partial types.SiderParsedResponse) error {
+        rs.resp = converter.NewResponsesObject(responseID(partial), answeredModel(c, p, req.Model, partial), req)
+        rs.suffix = converter.ResponsesItemSuffix(rs.resp.ID)
+        rs.w = startSSE(c, converter.SessionHeadersFromSider(partial))
+        if err := rs.send("response.created", gin.H{"response": rs.resp}); err != nil {
+            return err
+        }
+        return rs.send("response.in_progress", gin.H{"response": rs.resp})
+    }
+
+    siderResp, err := p.ChatStream(ctx, preq, rs.callback)
+    usage := converter.ConvertSiderToAnthropic(siderResp, req.Model).Usage
+    if !rs.started {
+        setRetryHeader(c, siderResp.Attempts)
+    }
+    if h.clientGone(c, siderResp, usage) {
+        return
+    }
+    if err != nil {
+        if !rs.started {
+            h.writeOpenAIError(c, err, false)
+            return
+        }
+        m := mapError(err)
+        h.Logger.Warn("request failed", "path", c.Request.URL.Path, "status", m.OpenAIStatus, "type", m.OpenAIType, "error", err)
+        rs.failed = true
+        rs.closeItem(siderResp)
+        rs.resp.Status = "failed"
+        code := m.OpenAICode
+        if code == "" {
+            code = "server_error"
+        }
+        rs.resp.Error = &types.ResponsesError{Code: code, Message: err.Error()}
+        rs.send("response.failed", gin.H{"response": rs.resp})
+        return
+    }
+    c.Set("usage", usage)
+
+    if !rs.started {
+        // nothing was streamed (e.g. an empty reply); open now so the client still
+        // gets the full event sequence
+        rs.started = true
+        if rs.open(siderResp) != nil {
+            return
+        }
+    }
+    rs.closeItem(siderResp)
+    if !rs.hasMessage {
+        rs.startMessage()
+        rs.closeItem(siderResp)
+    }
+    rs.resp.Status = "completed"
+    rs.resp.Usage = converter.ResponsesUsage(usage, strings.Join(siderResp.ReasoningParts, ""))
+    rs.send("response.completed", gin.H{"response": rs.resp})
+}
+
+// responsesStream tracks the output item being written. Items are written one at a
+// time: reasoning, web_search_call or message, each closed before the next opens.
+type responsesStream struct {
+    c      *gin.Context
+    cancel context.CancelFunc
+    // open writes the headers and the response.created events.
+    open func(partial types.SiderParsedResponse) error
+
+    w      gin.ResponseWriter
+    resp   types.ResponsesResponse
+    suffix string
+    seq    int
+
+    started    bool
+    hasMessage bool
+    // failed marks an open message as incomplete when it is closed.
+    failed bool
+    // current is the open item's type ("" when none); itemID, toolID and text
+    // describe it.
+    current string
+    itemID  string
+    toolID  string
+    text    strings.Builder
+}
+
+// callback is the siderclient.StreamCallback for the stream.
+func (s *responsesStream) callback(evt types.SiderSSEResponse, partial types.SiderParsedResponse) {
+    if s.c.Request.Context().Err() != nil {
+        return
+    }
+    data := evt.Data
+    switch data.Type {
+    case "reasoning_content":
+        if data.ReasoningContent == nil || data.ReasoningContent.Text == "" {
+            return
+        }
+    case "text":
+        if data.Text == "" {
+            return
+        }
+    case "tool_call_start", "tool_call_progress", "tool_call_result", "tool_call":
+        if data.ToolCall == nil || (data.ToolCall.Name != "search" && data.ToolCall.Name != "web_search") {
+            return
+        }
+    default:
+        return
+    }
+
+    if !s.started {
+        s.started = true
+        if err := s.open(partial); err != nil {
+            s.cancel()
+            return
+        }
+    }
+    if err := s.relay(data, partial); err != nil {
+        // the client is gone; stop Sider instead of generating into the void
+        s.cancel()
+    }
+}
+
+func (s *responsesStream) relay(data types.SiderResponseData, partial types.SiderParsedResponse) error {
+    switch data.Type {
+    case "reasoning_content":
+        if s.current != "reasoning" {
+            s.closeItem(partial)
+            s.itemID = "rs_" + s.suffix
+            s.current = "reasoning"
+            s.send("response.output_item.added", gin.H{"output_index": len(s.resp.Output), "item": gin.H{"id": s.itemID, "type": "reasoning", "summary": []any{}}})
+            s.send("response.reasoning_summary_part.added", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output), "summary_index": 0, "part": types.ResponsesSummaryText{Type: "summary_text"}})
+        }
+        s.text.WriteString(data.ReasoningContent.Text)
+        return s.send("response.reasoning_summary_text.delta", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output), "summary_index": 0, "delta": data.ReasoningContent.Text})
+    case "text":
+        if s.current != "message" {
+            s.closeItem(partial)
+            s.startMessage()
+        }
+        s.text.WriteString(data.Text)
+        return s.send("response.output_text.delta", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output), "content_index": 0, "delta": data.Text})
+    default:
+        tc := data.ToolCall
+        if s.current != "web_search_call" || s.toolID != tc.ID {
+            s.closeItem(partial)
+            s.itemID = "ws_" + tc.ID
+            s.toolID = tc.ID
+            s.current = "web_search_call"
+            s.send("response.output_item.added", gin.H{"output_index": len(s.resp.Output), "item": gin.H{"id": s.itemID, "type": "web_search_call", "status": "in_progress"}})
+            s.send("response.web_search_call.in_progress", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output)})
+        }
+        if data.Type == "tool_call_progress" {
+            return s.send("response.web_search_call.searching", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output)})
+        }
+        if data.Type == "tool_call_result" || tc.Status == "finish" || tc.Error != "" {
+            return s.closeItem(partial)
+        }
+        return nil
+    }
+}
+
+// startMessage opens the assistant message item.
+func (s *responsesStream) startMessage() {
+    s.itemID = "msg_" + s.suffix
+    s.current = "message"
+    s.hasMessage = true
+    s.send("response.output_item.added", gin.H{"output_index": len(s.resp.Output), "item": gin.H{"id": s.itemID, "type": "message", "status": "in_progress", "role": "assistant", "content": []any{}}})
+    s.send("response.content_part.added", gin.H{"item_id": s.itemID, "output_index": len(s.resp.Output), "content_index": 0, "part": types.ResponsesOutputText{Type: "output_text", Annotations: []any{}}})
+}
+
+// closeItem finishes the open item, if any, and adds it to the response output.
+func (s *responsesStream) closeItem(partial types.SiderParsedResponse) error {
+    index := len(s.resp.Output)
+    text := s.text.String()
+    var item types.ResponsesOutputItem
+    switch s.current {
+    case "":
+        return nil
+    case "reasoning":
+        item = converter.ResponsesReasoningItem(s.itemID, text)
+        s.send("response.reasoning_summary_text.done", gin.H{"item_id": s.itemID, "output_index": index, "summary_index": 0, "text": text})
+        s.send("response.reasoning_summary_part.done", gin.H{"item_id": s.itemID, "output_index": index, "summary_index": 0, "part": item.Summary[0]})
+    case "message":
+        status := "completed"
+        if s.failed {
+            status = "incomplete"
+        }
+        item = converter.ResponsesMessageItem(s.itemID, text, status)
+        s.send("response.output_text.done", gin.H{"item_id": s.itemID, "output_index": index, "content_index": 0, "text": text})
+        s.send("response.content_part.done", gin.H{"item_id": s.itemID, "output_index": index, "content_index": 0, "part": item.Content[0]})
+    case "web_search_call":
+        item = types.ResponsesOutputItem{ID: s.itemID, Type: "web_search_call", Status: "completed", Action: &types.ResponsesSearchAction{Type: "search"}}
+        for _, tr := range partial.ToolResults {
+            if tr.ToolID == s.toolID {
+                item, _ = converter.ResponsesSearchItem(tr)
+            }
+        }
+        s.send("response.web_search_call.completed", gin.H{"item_id": s.itemID, "output_index": index})
+    }
+    s.resp.Output = append(s.resp.Output, item)
+    s.current, s.itemID, s.toolID = "", "", ""
+    s.text.Reset()
+    return s.send("response.output_item.done", gin.H{"output_index": index, "item": item})
+}
+
+// send writes one typed event with its sequence number.
+func (s *responsesStream) send(event string, payload gin.H) error {
+    payload["type"] = event
+    payload["sequence_number"] = s.seq
+    s.seq++
+    data, _ := json.Marshal(payload)
+    if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+        return err
+    }
+    s.w.Flush()
+    return nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/pkg/types"
+)
+
+// clientClosedRequest is the status recorded when the client disconnects before a
+// response was written.
+const clientClosedRequest = 499
+
+// liveStream relays Sider text as it arrives. The SSE response only starts with the
+// first text or reasoning delta, so failures before that still get a normal error
+// response. Reasoning is wrapped in <think> tags like the buffered response.
+type liveStream struct {
+    c      *gin.Context
+    cancel context.CancelFunc
+    // open writes the headers and opening events; partial carries the session ids.
+    open func(partial types.SiderParsedResponse) error
+    // emit writes one text delta.
+    emit func(text string) error
+
+    started  bool
+    thinking bool
+}
+
+// callback is the siderclient.StreamCallback for the stream.
+func (s *liveStream) callback(evt types.SiderSSEResponse, partial types.SiderParsedResponse) {
+    if s.c.Request.Context().Err() != nil {
+        return
+    }
+    var text string
+    switch evt.Data.Type {
+    case "reasoning_content":
+        rc := evt.Data.ReasoningContent
+        if rc == nil || rc.Text == "" {
+            return
+        }
+        if !s.thinking {
+            s.thinking = true
+            text = "<think>\n"
+        }
+        text += rc.Text
+    case "text":
+        if evt.Data.Text == "" {
+            return
+        }
+        text = s.closeThink() + evt.Data.Text
+    default:
+        return
+    }
+
+    if !s.started {
+        s.started = true
+        if err := s.open(partial); err != nil {
+            s.cancel()
+            return
+        }
+    }
+    if err := s.emit(text); err != nil {
+        // the client is gone; stop Sider instead of generating into the void
+        s.cancel()
+    }
+}
+
+// closeThink returns the closing think tag when reasoning is still open.
+func (s *liveStream) closeThink() string {
+    if !s.thinking {
+        return ""
+    }
+    s.thinking = false
+    return "\n</think>\n\n"
+}
+
+// sseSender writes data-only SSE events to the client and flushes each one.
+func sseSender(w gin.ResponseWriter) func(payload any) error {
+    return func(payload any) error {
+        data, _ := json.Marshal(payload)
+        if _, err := w.Write([]byte("data: ")); err != nil {
+            return err
+        }
+        if _, err := w.Write(data); err != nil {
+            return err
+        }
+        if _, err := w.Write([]byte("\n\n")); err != nil {
+            return err
+        }
+        w.Flush()
+        return nil
+    }
+}
+
+// clientGone reports whether the request ended because the client disconnected. The
+// upstream call has been canceled by then; the partial output is logged and metered.
+func (h *Handler) clientGone(c *gin.Context, partial types.SiderParsedResponse, usage types.AnthropicUsage) bool {
+    if c.Request.Context().Err() == nil {
+        return false
+    }
+    h.Logger.Info("client disconnected, upstream generation canceled",
+        "path", c.Request.URL.Path,
+        "cid", partial.ConversationID,
+        "text_bytes", len(strings.Join(partial.TextParts, "")),
+        "reasoning_parts", len(partial.ReasoningParts),
+    )
+    if c.Writer.Written() {
+        // part of the answer reached the client, so its tokens count
+        c.Set("usage", usage)
+    } else {
+        c.Status(clientClosedRequest)
+    }
+    c.Abort()
+    return true
+}
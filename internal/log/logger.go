@@ -1,6 +1,7 @@
 package log
 
 import (
+    "io"
     "log/slog"
     "os"
     "strings"
@@ -8,8 +9,13 @@ import (
 
 // New constructs a slog.Logger with the requested level.
 func New(level string) *slog.Logger {
+    return NewWriter(level, os.Stdout)
+}
+
+// NewWriter constructs a slog.Logger writing to w, e.g. stderr or io.Discard for interactive UIs.
+func NewWriter(level string, w io.Writer) *slog.Logger {
     handlerOpts := &slog.HandlerOptions{Level: levelFromString(level)}
-    handler := slog.NewTextHandler(os.Stdout, handlerOpts)
+    handler := slog.NewTextHandler(w, handlerOpts)
     return slog.New(handler)
 }
 
@@ -0,0 +1,141 @@
+package mockupstream
+
+import (
+    _ "embed"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+)
+
+//go:embed scenarios.yaml
+var defaultScenarios []byte
+
+// Scenario scripts one kind of upstream reply. A request picks the first scenario
+// whose Match appears in the prompt (and whose Model, when set, equals the request
+// model); otherwise the default scenario answers.
+type Scenario struct {
+    Name    string `yaml:"name"`
+    Match   string `yaml:"match"`
+    Model   string `yaml:"model"`
+    Default bool   `yaml:"default"`
+
+    // Status and Body replace the stream with a plain response, e.g. a 401 or a
+    // 200 JSON error envelope the way Sider reports exhausted credits.
+    Status int    `yaml:"status"`
+    Body   string `yaml:"body"`
+
+    // FirstEventDelay is the wait before message_start; Delay is the wait between
+    // the events after it.
+    FirstEventDelay time.Duration `yaml:"first_event_delay"`
+    Delay           time.Duration `yaml:"delay"`
+
+    // Reasoning chunks are only sent when the request enables think mode.
+    Reasoning []string      `yaml:"reasoning"`
+    Search    *SearchScript `yaml:"search"`
+    // Text chunks support the {{prompt}} and {{model}} placeholders.
+    Text []string `yaml:"text"`
+    // Error is sent as an in-stream error event after the text.
+    Error *StreamError `yaml:"error"`
+    // Truncate ends the stream after the text without [DONE], like a dropped connection.
+    Truncate bool `yaml:"truncate"`
+}
+
+// SearchScript emits tool_call_start, tool_call_progress and tool_call_result events.
+type SearchScript struct {
+    Query   string         `yaml:"query"`
+    Results []SearchResult `yaml:"results"`
+}
+
+// SearchResult is one web result in a scripted search.
+type SearchResult struct {
+    Title   string `yaml:"title" json:"title"`
+    URL     string `yaml:"url" json:"url"`
+    Snippet string `yaml:"snippet" json:"snippet"`
+}
+
+// StreamError is a non-zero code event inside the stream.
+type StreamError struct {
+    Code int    `yaml:"code"`
+    Msg  string `yaml:"msg"`
+}
+
+type scenarioFile struct {
+    Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// DefaultScenarios returns the built-in scenarios.
+func DefaultScenarios() ([]Scenario, error) {
+    return parseScenarios(defaultScenarios)
+}
+
+// LoadScenarios reads scenarios from a YAML file; an empty path loads the built-in set.
+func LoadScenarios(path string) ([]Scenario, error) {
+    if path == "" {
+        return DefaultScenarios()
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    scenarios, err := parseScenarios(data)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    return scenarios, nil
+}
+
+func parseScenarios(data []byte) ([]Scenario, error) {
+    var f scenarioFile
+    if err := yaml.Unmarshal(data, &f); err != nil {
+        return nil, err
+    }
+    if len(f.Scenarios) == 0 {
+        return nil, errors.New("no scenarios defined")
+    }
+    seen := map[string]bool{}
+    for i, s := range f.Scenarios {
+        if s.Name == "" {
+            return nil, fmt.Errorf("scenario %d has no name", i+1)
+        }
+        if seen[s.Name] {
+            return nil, fmt.Errorf("duplicate scenario %q", s.Name)
+        }
+        seen[s.Name] = true
+        if s.Delay < 0 || s.FirstEventDelay < 0 {
+            return nil, fmt.Errorf("scenario %q: delays must not be negative", s.Name)
+        }
+        if s.Status != 0 && (s.Status < 100 || s.Status > 599) {
+            return nil, fmt.Errorf("scenario %q: invalid status %d", s.Name, s.Status)
+        }
+    }
+    return f.Scenarios, nil
+}
+
+// pick returns the scenario for a prompt and model.
+func pick(scenarios []Scenario, prompt, model string) Scenario {
+    lower := strings.ToLower(prompt)
+    for _, s := range scenarios {
+        if s.Match == "" || !strings.Contains(lower, strings.ToLower(s.Match)) {
+            continue
+        }
+        if s.Model != "" && s.Model != model {
+            continue
+        }
+        return s
+    }
+    for _, s := range scenarios {
+        if s.Default {
+            return s
+        }
+    }
+    return scenarios[0]
+}
+
+// expand fills the {{prompt}} and {{model}} placeholders.
+func expand(s, prompt, model string) string {
+    return strings.NewReplacer("{{prompt}}", prompt, "{{model}}", model).Replace(s)
+}
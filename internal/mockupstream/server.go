@@ -0,0 +1,295 @@
+// Package mockupstream is a local stand-in for the Sider chat and conversation
+// endpoints, driven by scripted scenarios.
+package mockupstream
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "sider2api/internal/models"
+    "sider2api/pkg/types"
+)
+
+// Paths mirror the real Sider endpoints so only the host changes in SIDER_BASE_URL
+// and SIDER_CONVERSATION_URL.
+const (
+    ChatPath         = "/api/chat/v1/completions"
+    ConversationPath = "/api/chat/v1/conversation/messages"
+    // ModelsPath is where the stand-in serves the model list for SIDER_MODELS_URL.
+    ModelsPath = "/api/chat/v1/models"
+)
+
+// Server answers chat requests from scenarios and remembers conversations so the
+// history endpoint returns what was said.
+type Server struct {
+    Scenarios []Scenario
+    // Models is the list served at ModelsPath; New fills in the registry's Sider names.
+    Models []string
+    Logger *slog.Logger
+
+    mu            sync.Mutex
+    seq           int
+    conversations map[string][]types.SiderConversationMessage
+}
+
+// New returns a server for scenarios.
+func New(scenarios []Scenario, logger *slog.Logger) *Server {
+    if logger == nil {
+        logger = slog.Default()
+    }
+    var names []string
+    for _, m := range models.Builtin().Models() {
+        names = append(names, m.UpstreamName())
+    }
+    return &Server{Scenarios: scenarios, Models: names, Logger: logger, conversations: map[string][]types.SiderConversationMessage{}}
+}
+
+// Handler returns the HTTP handler for the chat, conversation and model list endpoints.
+func (s *Server) Handler() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc(ChatPath, s.handleChat)
+    mux.HandleFunc(ConversationPath, s.handleConversation)
+    mux.HandleFunc(ModelsPath, s.handleModels)
+    return mux
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if !authorized(w, r) {
+        return
+    }
+    var req types.SiderRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        writeEnvelope(w, http.StatusBadRequest, 400, "invalid request body: "+err.Error())
+        return
+    }
+
+    prompt := promptText(req)
+    sc := pick(s.Scenarios, prompt, req.Model)
+    s.Logger.Info("mock chat request", "scenario", sc.Name, "model", req.Model, "cid", req.CID, "parent", req.ParentMessageID)
+
+    if !sleep(r.Context(), sc.FirstEventDelay) {
+        return
+    }
+    if sc.Status != 0 || sc.Body != "" {
+        status := sc.Status
+        if status == 0 {
+            status = http.StatusOK
+        }
+        if json.Valid([]byte(sc.Body)) {
+            w.Header().Set("Content-Type", "application/json")
+        }
+        w.WriteHeader(status)
+        w.Write([]byte(sc.Body))
+        return
+    }
+
+    cid, userID, assistantID := s.startTurn(req)
+    w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.WriteHeader(http.StatusOK)
+    flusher, _ := w.(http.Flusher)
+
+    ok := true
+    send := func(data types.SiderResponseData) {
+        if !ok {
+            return
+        }
+        data.Model = req.Model
+        ok = writeEvent(w, flusher, types.SiderSSEResponse{Data: data}) && sleep(r.Context(), sc.Delay)
+    }
+
+    send(types.SiderResponseData{Type: "message_start", MessageStart: &types.SiderMessageStart{CID: cid, UserMessageID: userID, AssistantMessageID: assistantID}})
+    if req.ThinkMode != nil && req.ThinkMode.Enable {
+        for _, chunk := range sc.Reasoning {
+            send(types.SiderResponseData{Type: "reasoning_content", ReasoningContent: &types.SiderReasoningContent{Status: "processing", Text: expand(chunk, prompt, req.Model)}})
+        }
+    }
+    if sc.Search != nil {
+        s.sendSearch(strings.Replace(userID, "user", "tool", 1), sc.Search, prompt, req.Model, send)
+    }
+    var reply strings.Builder
+    for _, chunk := range sc.Text {
+        text := expand(chunk, prompt, req.Model)
+        reply.WriteString(text)
+        send(types.SiderResponseData{Type: "text", Text: text})
+    }
+    if !ok {
+        s.Logger.Info("mock chat canceled by client", "scenario", sc.Name, "cid", cid)
+        return
+    }
+
+    if sc.Error != nil {
+        writeEvent(w, flusher, types.SiderSSEResponse{Code: sc.Error.Code, Msg: sc.Error.Msg})
+        return
+    }
+    if sc.Truncate {
+        return
+    }
+    s.finishTurn(cid, userID, assistantID, req.Model, reply.String())
+    w.Write([]byte("data: [DONE]\n\n"))
+    if flusher != nil {
+        flusher.Flush()
+    }
+}
+
+func (s *Server) sendSearch(id string, script *SearchScript, prompt, model string, send func(types.SiderResponseData)) {
+    query := expand(script.Query, prompt, model)
+    send(types.SiderResponseData{Type: "tool_call_start", ToolCall: &types.SiderToolCall{ID: id, Name: "search", Status: "start"}})
+    send(types.SiderResponseData{Type: "tool_call_progress", ToolCall: &types.SiderToolCall{ID: id, Name: "search", Status: "searching", Progress: map[string]any{"query": query}}})
+    send(types.SiderResponseData{Type: "tool_call_result", ToolCall: &types.SiderToolCall{ID: id, Name: "search", Status: "finish", Search: map[string]any{"query": query, "results": script.Results}}})
+}
+
+// startTurn records the user message and returns the ids for message_start. A
+// request without a cid opens a new conversation.
+func (s *Server) startTurn(req types.SiderRequest) (cid, userID, assistantID string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.seq++
+    cid = req.CID
+    if cid == "" {
+        cid = fmt.Sprintf("mock-cid-%d", s.seq)
+    }
+    userID = fmt.Sprintf("mock-user-%d", s.seq)
+    assistantID = fmt.Sprintf("mock-assistant-%d", s.seq)
+    s.conversations[cid] = append(s.conversations[cid], types.SiderConversationMessage{
+        ID:              userID,
+        ParentMessageID: req.ParentMessageID,
+        Role:            "user",
+        Model:           req.Model,
+        MultiContent:    []types.SiderConversationContent{{Type: "text", Text: promptText(req), UserInputText: promptText(req)}},
+    })
+    return cid, userID, assistantID
+}
+
+// finishTurn records the assistant reply once the stream completed.
+func (s *Server) finishTurn(cid, userID, assistantID, model, text string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.conversations[cid] = append(s.conversations[cid], types.SiderConversationMessage{
+        ID:              assistantID,
+        ParentMessageID: userID,
+        Role:            "assistant",
+        Model:           model,
+        MultiContent:    []types.SiderConversationContent{{Type: "text", Text: text}},
+    })
+}
+
+func (s *Server) handleConversation(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if !authorized(w, r) {
+        return
+    }
+    var body struct {
+        CID   string `json:"cid"`
+        Limit int    `json:"limit"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+        writeEnvelope(w, http.StatusBadRequest, 400, "invalid request body: "+err.Error())
+        return
+    }
+
+    s.mu.Lock()
+    msgs, ok := s.conversations[body.CID]
+    msgs = append([]types.SiderConversationMessage(nil), msgs...)
+    s.mu.Unlock()
+    if !ok {
+        writeEnvelope(w, http.StatusOK, 404, "conversation not found")
+        return
+    }
+    if body.Limit > 0 && len(msgs) > body.Limit {
+        msgs = msgs[len(msgs)-body.Limit:]
+    }
+    s.Logger.Info("mock conversation request", "cid", body.CID, "messages", len(msgs))
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]any{
+        "code": 0,
+        "msg":  "",
+        "data": map[string]any{"conversation": map[string]any{"cid": body.CID}, "messages": msgs},
+    })
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if !authorized(w, r) {
+        return
+    }
+    list := make([]map[string]any, 0, len(s.Models))
+    for _, name := range s.Models {
+        list = append(list, map[string]any{"model": name, "name": name})
+    }
+    s.Logger.Info("mock models request", "models", len(list))
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(map[string]any{"code": 0, "msg": "", "data": map[string]any{"models": list}})
+}
+
+// authorized rejects requests without a bearer token the way Sider does.
+func authorized(w http.ResponseWriter, r *http.Request) bool {
+    if strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) == "" {
+        writeEnvelope(w, http.StatusUnauthorized, 401, "unauthorized")
+        return false
+    }
+    return true
+}
+
+func writeEnvelope(w http.ResponseWriter, status, code int, msg string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(map[string]any{"code": code, "msg": msg, "data": map[string]any{}})
+}
+
+// writeEvent sends one SSE event and reports whether the client is still there.
+func writeEvent(w http.ResponseWriter, flusher http.Flusher, evt types.SiderSSEResponse) bool {
+    data, _ := json.Marshal(evt)
+    if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+        return false
+    }
+    if flusher != nil {
+        flusher.Flush()
+    }
+    return true
+}
+
+// sleep waits d unless ctx ends first, reporting whether the wait completed.
+func sleep(ctx context.Context, d time.Duration) bool {
+    if d <= 0 {
+        return ctx.Err() == nil
+    }
+    t := time.NewTimer(d)
+    defer t.Stop()
+    select {
+    case <-t.C:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
+
+// promptText is the latest user input in the request.
+func promptText(req types.SiderRequest) string {
+    for i := len(req.MultiContent) - 1; i >= 0; i-- {
+        mc := req.MultiContent[i]
+        if mc.UserInputText != "" {
+            return mc.UserInputText
+        }
+        if mc.Text != "" {
+            return mc.Text
+        }
+    }
+    return ""
+}
@@ -0,0 +1,74 @@
+package mockupstream
+
+import (
+    "io"
+    "net/http"
+    "sync"
+)
+
+// Transport is an http.RoundTripper that serves requests from an in-process handler.
+// The body streams as the handler writes it, so delays between events reach the
+// caller the same way they would over the network, and closing the body or
+// canceling the request stops the handler.
+type Transport struct {
+    Handler http.Handler
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+    pr, pw := io.Pipe()
+    rw := &pipeResponse{header: http.Header{}, body: pw, ready: make(chan struct{})}
+    go func() {
+        defer func() {
+            rw.WriteHeader(http.StatusOK)
+            pw.Close()
+        }()
+        t.Handler.ServeHTTP(rw, req)
+    }()
+
+    select {
+    case <-rw.ready:
+    case <-req.Context().Done():
+        pr.CloseWithError(req.Context().Err())
+        return nil, req.Context().Err()
+    }
+    return &http.Response{
+        Status:        http.StatusText(rw.status),
+        StatusCode:    rw.status,
+        Proto:         "HTTP/1.1",
+        ProtoMajor:    1,
+        ProtoMinor:    1,
+        Header:        rw.sent,
+        Body:          pr,
+        ContentLength: -1,
+        Request:       req,
+    }, nil
+}
+
+// pipeResponse is the http.ResponseWriter half of Transport.
+type pipeResponse struct {
+    header http.Header
+    sent   http.Header
+    body   *io.PipeWriter
+    status int
+    once   sync.Once
+    ready  chan struct{}
+}
+
+func (w *pipeResponse) Header() http.Header { return w.header }
+
+func (w *pipeResponse) WriteHeader(status int) {
+    w.once.Do(func() {
+        w.status = status
+        w.sent = w.header.Clone()
+        close(w.ready)
+    })
+}
+
+func (w *pipeResponse) Write(p []byte) (int, error) {
+    w.WriteHeader(http.StatusOK)
+    return w.body.Write(p)
+}
+
+// Flush is a no-op; every Write already reaches the reader.
+func (w *pipeResponse) Flush() {}
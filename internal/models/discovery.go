@@ -0,0 +1,160 @@
+package models
+
+import (
+    "context"
+    "log/slog"
+    "strings"
+    "sync"
+    "time"
+)
+
+// discoveryTimeout bounds one fetch of the live model list.
+const discoveryTimeout = 30 * time.Second
+
+// Discovery keeps the registry in step with the models Sider lists. The live list is
+// fetched on demand and cached for TTL. A failed fetch keeps the last good result, or
+// the static registry, and is retried after a minute at most.
+type Discovery struct {
+    Base    *Registry
+    TTL     time.Duration
+    Timeout time.Duration
+    Fetch   func(ctx context.Context) ([]Model, error)
+    Logger  *slog.Logger
+
+    mu       sync.Mutex
+    next     time.Time
+    merged   *Registry
+    fetching chan struct{} // closed when the fetch in flight ends
+}
+
+// NewDiscovery returns a discovery that merges the models fetch returns into base.
+func NewDiscovery(base *Registry, ttl time.Duration, fetch func(ctx context.Context) ([]Model, error), logger *slog.Logger) *Discovery {
+    if logger == nil {
+        logger = slog.Default()
+    }
+    return &Discovery{Base: base, TTL: ttl, Timeout: discoveryTimeout, Fetch: fetch, Logger: logger}
+}
+
+// Registry returns the static registry merged with the cached live list. When the
+// cache has expired the caller starts a fetch and waits for it until ctx ends; callers
+// arriving while a fetch is in flight get the current list without waiting. The fetch
+// runs on its own context, so a caller going away neither cancels nor fails it.
+func (d *Discovery) Registry(ctx context.Context) *Registry {
+    d.mu.Lock()
+    current := d.merged
+    if current != nil && time.Now().Before(d.next) {
+        d.mu.Unlock()
+        return current
+    }
+    if current == nil {
+        current = d.Base.orBuiltin()
+    }
+    if d.fetching != nil {
+        d.mu.Unlock()
+        return current
+    }
+    done := make(chan struct{})
+    d.fetching = done
+    d.mu.Unlock()
+
+    go d.refresh(done)
+    select {
+    case <-done:
+    case <-ctx.Done():
+        return current
+    }
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    return d.merged
+}
+
+// refresh fetches the live list, updates the cache and closes done.
+func (d *Discovery) refresh(done chan struct{}) {
+    defer close(done)
+    timeout := d.Timeout
+    if timeout <= 0 {
+        timeout = discoveryTimeout
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+    live, err := d.Fetch(ctx)
+
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.fetching = nil
+    now := time.Now()
+    if err != nil {
+        d.next = now.Add(min(d.TTL, time.Minute))
+        d.Logger.Warn("model discovery failed", "error", err)
+        if d.merged == nil {
+            d.merged = d.Base.orBuiltin()
+        }
+        return
+    }
+    d.next = now.Add(d.TTL)
+    added, retired := Diff(d.Base, live)
+    d.merged = d.Base.Merge(live)
+    d.Logger.Info("model list refreshed", "live", len(live), "new", len(added), "retired", len(retired))
+}
+
+// Diff compares a live model list with r. Added are the live models r does not know
+// by id, alias or upstream name; retired are the models in r that the live list no
+// longer names.
+func Diff(r *Registry, live []Model) (added, retired []Model) {
+    r = r.orBuiltin()
+    seen := map[int]bool{}
+    names := map[string]bool{}
+    for _, m := range live {
+        key := strings.ToLower(m.ID)
+        if m.ID == "" || names[key] {
+            continue
+        }
+        names[key] = true
+        if i, ok := r.match(m.ID); ok {
+            seen[i] = true
+            continue
+        }
+        added = append(added, m)
+    }
+    for i, m := range r.models {
+        if !seen[i] {
+            retired = append(retired, m)
+        }
+    }
+    return added, retired
+}
+
+// Merge returns a registry with the live models r does not know appended. Known models
+// keep their registry entry, and retired ones stay listed. Aliases sent with new
+// models are dropped so they cannot clash with the registry's.
+func (r *Registry) Merge(live []Model) *Registry {
+    r = r.orBuiltin()
+    added, _ := Diff(r, live)
+    if len(added) == 0 {
+        return r
+    }
+    list := append([]Model(nil), r.models...)
+    for _, m := range added {
+        m.Aliases = nil
+        list = append(list, m)
+    }
+    merged, err := build(r.def, list)
+    if err != nil {
+        // only reachable through a clash between ids; keep the registry as it was
+        return r
+    }
+    return merged
+}
+
+// match finds a model by id, alias or upstream name and returns its index.
+func (r *Registry) match(name string) (int, bool) {
+    if i, ok := r.byName[strings.ToLower(strings.TrimSpace(name))]; ok {
+        return i, true
+    }
+    for i, m := range r.models {
+        if strings.EqualFold(m.UpstreamName(), name) {
+            return i, true
+        }
+    }
+    return 0, false
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+    "context"
+    "errors"
+    "io"
+    "log/slog"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+var quietLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func ids(list []Model) []string {
+    out := make([]string, 0, len(list))
+    for _, m := range list {
+        out = append(out, m.ID)
+    }
+    return out
+}
+
+func TestDiffAndMerge(t *testing.T) {
+    base := Builtin()
+    live := []Model{
+        {ID: "claude-haiku-4.5"},
+        {ID: "claude-sonnet-4-5"}, // alias of claude-4.5-sonnet
+        {ID: "GPT-5-MINI"},
+        {ID: "brand-new", Aliases: []string{"claude-haiku"}},
+        {ID: "brand-new"},
+        {ID: ""},
+    }
+    added, retired := Diff(base, live)
+    if got := ids(added); len(got) != 1 || got[0] != "brand-new" {
+        t.Fatalf("added = %v, want [brand-new]", got)
+    }
+    if len(retired) != len(base.Models())-3 {
+        t.Fatalf("retired = %v, want every model but the three listed", ids(retired))
+    }
+
+    merged := base.Merge(live)
+    if len(merged.Models()) != len(base.Models())+1 {
+        t.Fatalf("merged = %v", merged.IDs())
+    }
+    if m, ok := merged.Lookup("brand-new"); !ok || len(m.Aliases) != 0 {
+        t.Fatalf("brand-new = %+v, %v; want it without aliases", m, ok)
+    }
+    // the new model's alias must not steal an existing one
+    if m, _ := merged.Lookup("claude-haiku"); m.ID != "claude-haiku-4.5" {
+        t.Fatalf("claude-haiku resolves to %s", m.ID)
+    }
+    if merged.Default().ID != base.Default().ID {
+        t.Fatalf("default = %s, want %s", merged.Default().ID, base.Default().ID)
+    }
+    if base.Merge([]Model{{ID: "gpt-5.1"}}) != base {
+        t.Error("merge without new models built a new registry")
+    }
+}
+
+func TestDiscoveryCaches(t *testing.T) {
+    var calls atomic.Int32
+    var fail atomic.Bool
+    d := NewDiscovery(Builtin(), time.Hour, func(ctx context.Context) ([]Model, error) {
+        calls.Add(1)
+        if fail.Load() {
+            return nil, errors.New("upstream down")
+        }
+        return []Model{{ID: "brand-new"}}, nil
+    }, quietLogger)
+
+    for i := 0; i < 3; i++ {
+        if _, ok := d.Registry(context.Background()).Lookup("brand-new"); !ok {
+            t.Fatalf("call %d: discovered model missing", i)
+        }
+    }
+    if n := calls.Load(); n != 1 {
+        t.Fatalf("fetches = %d within the TTL, want 1", n)
+    }
+
+    // an expired cache whose refresh fails keeps the last good list
+    d.TTL = 0
+    d.next = time.Time{}
+    fail.Store(true)
+    if _, ok := d.Registry(context.Background()).Lookup("brand-new"); !ok {
+        t.Fatal("failed refresh dropped the last good list")
+    }
+    if n := calls.Load(); n != 2 {
+        t.Fatalf("fetches = %d, want a second one after expiry", n)
+    }
+}
+
+func TestDiscoveryFailureFallsBackToBase(t *testing.T) {
+    base := Builtin()
+    d := NewDiscovery(base, time.Hour, func(ctx context.Context) ([]Model, error) {
+        return nil, errors.New("upstream down")
+    }, quietLogger)
+    if got := d.Registry(context.Background()); got != base {
+        t.Fatalf("registry after failed first fetch = %v, want the base", got.IDs())
+    }
+}
+
+func TestDiscoveryFetchOutlivesCaller(t *testing.T) {
+    release := make(chan struct{})
+    fetchErr := make(chan error, 1)
+    var calls atomic.Int32
+    d := NewDiscovery(Builtin(), time.Hour, func(ctx context.Context) ([]Model, error) {
+        calls.Add(1)
+        <-release
+        fetchErr <- ctx.Err()
+        return []Model{{ID: "brand-new"}}, nil
+    }, quietLogger)
+
+    // the caller that starts the fetch goes away before it finishes
+    ctx, cancel := context.WithCancel(context.Background())
+    go func() {
+        time.Sleep(20 * time.Millisecond)
+        cancel()
+    }()
+    if _, ok := d.Registry(ctx).Lookup("brand-new"); ok {
+        t.Fatal("canceled caller got the unfinished list")
+    }
+
+    // callers arriving during the fetch get the current list without waiting
+    done := make(chan *Registry)
+    go func() { done <- d.Registry(context.Background()) }()
+    select {
+    case r := <-done:
+        if _, ok := r.Lookup("brand-new"); ok {
+            t.Fatal("caller got the unfinished list")
+        }
+    case <-time.After(time.Second):
+        t.Fatal("caller waited for the fetch in flight")
+    }
+
+    close(release)
+    if err := <-fetchErr; err != nil {
+        t.Fatalf("fetch context ended with the caller: %v", err)
+    }
+    deadline := time.Now().Add(time.Second)
+    for {
+        if _, ok := d.Registry(context.Background()).Lookup("brand-new"); ok {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("finished fetch never reached the cache")
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    if n := calls.Load(); n != 1 {
+        t.Fatalf("fetches = %d, want 1", n)
+    }
+}
@@ -0,0 +1,186 @@
+// Package models is the registry of models the proxy offers: their ids, aliases,
+// Sider names and capabilities.
+package models
+
+import (
+    _ "embed"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "sync"
+)
+
+//go:embed models.json
+var builtinJSON []byte
+
+// Model is one registry entry.
+type Model struct {
+    ID      string   `json:"id"`
+    Aliases []string `json:"aliases,omitempty"`
+    // Upstream is the name sent to Sider; empty means the id.
+    Upstream       string `json:"upstream,omitempty"`
+    DisplayName    string `json:"display_name"`
+    OwnedBy        string `json:"owned_by,omitempty"`
+    ContextWindow  int    `json:"context_window,omitempty"`
+    SupportsThink  bool   `json:"supports_think"`
+    SupportsSearch bool   `json:"supports_search"`
+    SupportsVision bool   `json:"supports_vision"`
+    // SupportsImages is image generation.
+    SupportsImages bool `json:"supports_images"`
+}
+
+// UpstreamName is the model name sent to Sider.
+func (m Model) UpstreamName() string {
+    if m.Upstream != "" {
+        return m.Upstream
+    }
+    return m.ID
+}
+
+// Registry is an ordered set of models with lookup by id or alias.
+type Registry struct {
+    models []Model
+    byName map[string]int
+    def    string
+}
+
+type registryFile struct {
+    Default string  `json:"default"`
+    Models  []Model `json:"models"`
+}
+
+var (
+    builtinOnce sync.Once
+    builtin     *Registry
+)
+
+// Builtin returns the embedded registry.
+func Builtin() *Registry {
+    builtinOnce.Do(func() {
+        var f registryFile
+        if err := json.Unmarshal(builtinJSON, &f); err != nil {
+            panic("models: embedded registry: " + err.Error())
+        }
+        r, err := build(f.Default, f.Models)
+        if err != nil {
+            panic("models: embedded registry: " + err.Error())
+        }
+        builtin = r
+    })
+    return builtin
+}
+
+// Load returns the embedded registry overridden by the JSON file at path: entries
+// with a known id replace the built-in one, new ids are added, and a "default"
+// replaces the default model. An empty path returns the embedded registry.
+func Load(path string) (*Registry, error) {
+    if path == "" {
+        return Builtin(), nil
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read models file: %w", err)
+    }
+    var f registryFile
+    if err := json.Unmarshal(data, &f); err != nil {
+        return nil, fmt.Errorf("parse models file: %w", err)
+    }
+    base := Builtin()
+    merged := append([]Model(nil), base.models...)
+    for _, m := range f.Models {
+        if i, ok := base.byName[strings.ToLower(m.ID)]; ok && strings.EqualFold(base.models[i].ID, m.ID) {
+            merged[i] = m
+        } else {
+            merged = append(merged, m)
+        }
+    }
+    def := base.def
+    if f.Default != "" {
+        def = f.Default
+    }
+    r, err := build(def, merged)
+    if err != nil {
+        return nil, fmt.Errorf("models file %s: %w", path, err)
+    }
+    return r, nil
+}
+
+func build(def string, list []Model) (*Registry, error) {
+    if len(list) == 0 {
+        return nil, errors.New("no models defined")
+    }
+    r := &Registry{models: list, byName: map[string]int{}}
+    for i, m := range list {
+        if m.ID == "" {
+            return nil, fmt.Errorf("model %d has no id", i+1)
+        }
+        if m.DisplayName == "" {
+            list[i].DisplayName = m.ID
+        }
+        for _, name := range append([]string{m.ID}, m.Aliases...) {
+            key := strings.ToLower(name)
+            if j, dup := r.byName[key]; dup {
+                return nil, fmt.Errorf("%q is used by both %s and %s", name, list[j].ID, m.ID)
+            }
+            r.byName[key] = i
+        }
+    }
+    if def == "" {
+        def = list[0].ID
+    }
+    m, ok := r.Lookup(def)
+    if !ok {
+        return nil, fmt.Errorf("default model %q is not in the registry", def)
+    }
+    r.def = m.ID
+    return r, nil
+}
+
+// Models returns the models in registry order.
+func (r *Registry) Models() []Model {
+    return append([]Model(nil), r.orBuiltin().models...)
+}
+
+// IDs returns the model ids in registry order.
+func (r *Registry) IDs() []string {
+    list := r.orBuiltin().models
+    out := make([]string, len(list))
+    for i, m := range list {
+        out[i] = m.ID
+    }
+    return out
+}
+
+// Lookup finds a model by id or alias, case-insensitively.
+func (r *Registry) Lookup(name string) (Model, bool) {
+    r = r.orBuiltin()
+    i, ok := r.byName[strings.ToLower(strings.TrimSpace(name))]
+    if !ok {
+        return Model{}, false
+    }
+    return r.models[i], true
+}
+
+// Default returns the default model.
+func (r *Registry) Default() Model {
+    m, _ := r.Lookup(r.orBuiltin().def)
+    return m
+}
+
+// UpstreamName maps a requested model to its Sider name; unknown names pass through.
+func (r *Registry) UpstreamName(name string) string {
+    if m, ok := r.Lookup(name); ok {
+        return m.UpstreamName()
+    }
+    return name
+}
+
+// orBuiltin lets a nil *Registry stand for the embedded one.
+func (r *Registry) orBuiltin() *Registry {
+    if r == nil {
+        return Builtin()
+    }
+    return r
+}
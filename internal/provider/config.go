@@ -0,0 +1,191 @@
+package provider
+
+import (
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "sider2api/internal/config"
+    "sider2api/internal/models"
+    "sider2api/internal/siderclient"
+)
+
+// Spec is one provider entry in the providers file.
+type Spec struct {
+    Name    string `json:"name"`
+    Type    string `json:"type"`
+    BaseURL string `json:"base_url"`
+    // APIKey may reference environment variables, e.g. "$LOCAL_LLM_KEY".
+    APIKey  string   `json:"api_key,omitempty"`
+    Timeout string   `json:"timeout,omitempty"`
+    Models  []string `json:"models"`
+    // Capabilities defaults to streaming only.
+    Capabilities *Capabilities `json:"capabilities,omitempty"`
+}
+
+// RouteSpec is one routing rule in the providers file: a model alias served by an
+// ordered list of targets.
+type RouteSpec struct {
+    Model   string       `json:"model"`
+    Targets []TargetSpec `json:"targets"`
+    // FallbackOn lists error kinds such as "rate_limit"; empty uses DefaultFallbackOn.
+    FallbackOn []string `json:"fallback_on,omitempty"`
+    // FirstEventTimeout abandons a target that has not started answering in time.
+    FirstEventTimeout string `json:"first_event_timeout,omitempty"`
+}
+
+// TargetSpec names a route target. An empty Provider means whichever provider
+// serves Model.
+type TargetSpec struct {
+    Provider string `json:"provider,omitempty"`
+    Model    string `json:"model"`
+}
+
+// File is the providers file.
+type File struct {
+    Providers []Spec      `json:"providers"`
+    Routes    []RouteSpec `json:"routes"`
+}
+
+// LoadFile reads the providers file.
+func LoadFile(path string) (File, error) {
+    var f File
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return f, fmt.Errorf("read providers file: %w", err)
+    }
+    if err := json.Unmarshal(data, &f); err != nil {
+        return f, fmt.Errorf("parse providers file: %w", err)
+    }
+    return f, nil
+}
+
+// FromConfig builds the router: Sider serves every model unless PROVIDERS_FILE
+// assigns it to another provider or routes it.
+func FromConfig(cfg config.Config, client *siderclient.Client, registry *models.Registry, logger *slog.Logger) (*Router, error) {
+    if logger == nil {
+        logger = slog.Default()
+    }
+    sider := &Sider{Client: client, Registry: registry}
+    r := NewRouter(sider)
+    if cfg.ProvidersFile == "" {
+        return r, nil
+    }
+    f, err := LoadFile(cfg.ProvidersFile)
+    if err != nil {
+        return nil, err
+    }
+    specs := f.Providers
+    names := map[string]bool{sider.Name(): true}
+    claimed := map[string]string{}
+    for i, s := range specs {
+        if s.Name == "" {
+            return nil, fmt.Errorf("provider %d has no name", i+1)
+        }
+        if names[s.Name] {
+            return nil, fmt.Errorf("duplicate provider %q", s.Name)
+        }
+        names[s.Name] = true
+        for _, m := range s.Models {
+            if other, ok := claimed[strings.ToLower(m)]; ok {
+                return nil, fmt.Errorf("model %q is assigned to both %s and %s", m, other, s.Name)
+            }
+            claimed[strings.ToLower(m)] = s.Name
+        }
+        p, err := newProvider(s, cfg, logger)
+        if err != nil {
+            return nil, fmt.Errorf("provider %s: %w", s.Name, err)
+        }
+        r.Add(p, s.Models)
+        logger.Info("provider configured", "provider", s.Name, "type", s.Type, "base_url", p.BaseURL, "models", len(s.Models))
+    }
+
+    aliases := map[string]bool{}
+    for i, rs := range f.Routes {
+        route, err := newRoute(rs, r, registry, logger)
+        if err != nil {
+            return nil, fmt.Errorf("route %d: %w", i+1, err)
+        }
+        if aliases[strings.ToLower(rs.Model)] {
+            return nil, fmt.Errorf("duplicate route for %q", rs.Model)
+        }
+        aliases[strings.ToLower(rs.Model)] = true
+        r.AddRoute(route)
+        logger.Info("model route configured", "model", rs.Model, "targets", len(route.Targets), "first_event_timeout", route.FirstEventTimeout)
+    }
+    return r, nil
+}
+
+func newRoute(rs RouteSpec, r *Router, registry *models.Registry, logger *slog.Logger) (*Route, error) {
+    if rs.Model == "" {
+        return nil, fmt.Errorf("model is required")
+    }
+    if len(rs.Targets) == 0 {
+        return nil, fmt.Errorf("%s: no targets", rs.Model)
+    }
+    route := &Route{Alias: rs.Model, FallbackOn: DefaultFallbackOn, Registry: registry, Logger: logger}
+    for _, ts := range rs.Targets {
+        if ts.Model == "" {
+            return nil, fmt.Errorf("%s: target without a model", rs.Model)
+        }
+        p := r.direct(ts.Model)
+        if ts.Provider != "" {
+            var ok bool
+            if p, ok = r.Provider(ts.Provider); !ok {
+                return nil, fmt.Errorf("%s: unknown provider %q", rs.Model, ts.Provider)
+            }
+        }
+        route.Targets = append(route.Targets, Target{Provider: p, Model: ts.Model})
+    }
+    if len(rs.FallbackOn) > 0 {
+        kinds, err := siderclient.ParseErrorKinds(strings.Join(rs.FallbackOn, ","))
+        if err != nil {
+            return nil, fmt.Errorf("%s: fallback_on: %w", rs.Model, err)
+        }
+        route.FallbackOn = kinds
+    }
+    if rs.FirstEventTimeout != "" {
+        d, err := time.ParseDuration(rs.FirstEventTimeout)
+        if err != nil || d <= 0 {
+            return nil, fmt.Errorf("%s: invalid first_event_timeout %q", rs.Model, rs.FirstEventTimeout)
+        }
+        route.FirstEventTimeout = d
+    }
+    return route, nil
+}
+
+func newProvider(s Spec, cfg config.Config, logger *slog.Logger) (*OpenAI, error) {
+    if s.Type != "openai" {
+        return nil, fmt.Errorf("unknown type %q (want openai)", s.Type)
+    }
+    if s.BaseURL == "" {
+        return nil, fmt.Errorf("base_url is required")
+    }
+    timeout := cfg.ChatTimeout
+    if s.Timeout != "" {
+        d, err := time.ParseDuration(s.Timeout)
+        if err != nil || d <= 0 {
+            return nil, fmt.Errorf("invalid timeout %q", s.Timeout)
+        }
+        timeout = d
+    }
+    caps := Capabilities{Streaming: true}
+    if s.Capabilities != nil {
+        caps = *s.Capabilities
+    }
+    // local servers are not Sider, so they bypass the upstream proxy and VCR
+    return &OpenAI{
+        ID:         s.Name,
+        BaseURL:    strings.TrimRight(s.BaseURL, "/"),
+        APIKey:     os.ExpandEnv(s.APIKey),
+        Timeout:    timeout,
+        Served:     s.Models,
+        Caps:       caps,
+        HTTPClient: &http.Client{},
+        Logger:     logger,
+    }, nil
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "sider2api/internal/siderclient"
+)
+
+func TestNewRoute(t *testing.T) {
+    sider := &stubProvider{name: "sider"}
+    local := &stubProvider{name: "local"}
+    r := NewRouter(sider)
+    r.Add(local, []string{"Llama-3"})
+
+    tests := []struct {
+        name      string
+        spec      RouteSpec
+        providers []string
+        fallback  []siderclient.ErrorKind
+        timeout   time.Duration
+        wantErr   string
+    }{
+        {
+            name:      "targets resolve to the provider serving their model",
+            spec:      RouteSpec{Model: "fast", Targets: []TargetSpec{{Model: "llama-3"}, {Model: "claude-haiku-4.5"}}},
+            providers: []string{"local", "sider"},
+            fallback:  DefaultFallbackOn,
+        },
+        {
+            name:      "explicit provider and options",
+            spec:      RouteSpec{Model: "fast", Targets: []TargetSpec{{Provider: "sider", Model: "llama-3"}}, FallbackOn: []string{"rate_limit", "timeout"}, FirstEventTimeout: "5s"},
+            providers: []string{"sider"},
+            fallback:  []siderclient.ErrorKind{siderclient.KindRateLimit, siderclient.KindTimeout},
+            timeout:   5 * time.Second,
+        },
+        {name: "no alias", spec: RouteSpec{Targets: []TargetSpec{{Model: "m"}}}, wantErr: "model is required"},
+        {name: "no targets", spec: RouteSpec{Model: "fast"}, wantErr: "no targets"},
+        {name: "target without model", spec: RouteSpec{Model: "fast", Targets: []TargetSpec{{Provider: "sider"}}}, wantErr: "without a model"},
+        {name: "unknown provider", spec: RouteSpec{Model: "fast", Targets: []TargetSpec{{Provider: "cloud", Model: "m"}}}, wantErr: "unknown provider"},
+        {name: "unknown error kind", spec: RouteSpec{Model: "fast", Targets: []TargetSpec{{Model: "m"}}, FallbackOn: []string{"sometimes"}}, wantErr: "fallback_on"},
+        {name: "bad timeout", spec: RouteSpec{Model: "fast", Targets: []TargetSpec{{Model: "m"}}, FirstEventTimeout: "-1s"}, wantErr: "first_event_timeout"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            route, err := newRoute(tt.spec, r, nil, nil)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("err = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            var providers []string
+            for _, target := range route.Targets {
+                providers = append(providers, target.Provider.Name())
+            }
+            if !reflect.DeepEqual(providers, tt.providers) {
+                t.Errorf("target providers = %v, want %v", providers, tt.providers)
+            }
+            if !reflect.DeepEqual(route.FallbackOn, tt.fallback) || route.FirstEventTimeout != tt.timeout {
+                t.Errorf("fallback_on %v timeout %v, want %v %v", route.FallbackOn, route.FirstEventTimeout, tt.fallback, tt.timeout)
+            }
+        })
+    }
+}
+
+func TestRouterFor(t *testing.T) {
+    sider := &stubProvider{name: "sider"}
+    local := &stubProvider{name: "local"}
+    r := NewRouter(sider)
+    r.Add(local, []string{"Llama-3"})
+    r.AddRoute(&Route{Alias: "Fast", Targets: []Target{{Provider: local, Model: "llama-3"}}})
+
+    for model, want := range map[string]string{"llama-3": "local", "LLAMA-3": "local", "fast": "route:Fast", "gpt-5-mini": "sider"} {
+        if got := r.For(model).Name(); got != want {
+            t.Errorf("For(%q) = %s, want %s", model, got, want)
+        }
+    }
+    if got := r.Names(); !reflect.DeepEqual(got, []string{"Llama-3", "Fast"}) {
+        t.Errorf("Names = %v", got)
+    }
+}
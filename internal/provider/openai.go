@@ -0,0 +1,337 @@
+package provider
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+
+    "sider2api/internal/converter"
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// OpenAI is a provider for any OpenAI-compatible chat completions endpoint, such as
+// llama.cpp, vLLM or Ollama servers. It is stateless: every turn resends the history.
+type OpenAI struct {
+    ID      string
+    BaseURL string
+    APIKey  string
+    Timeout time.Duration
+    // Served lists the models routed here; Caps applies to all of them.
+    Served     []string
+    Caps       Capabilities
+    HTTPClient *http.Client
+    Logger     *slog.Logger
+}
+
+func (p *OpenAI) Name() string { return p.ID }
+
+func (p *OpenAI) Capabilities(model string) Capabilities { return p.Caps }
+
+type openAIMessage struct {
+    Role    string `json:"role"`
+    Content any    `json:"content"`
+}
+
+type openAIRequest struct {
+    Model       string          `json:"model"`
+    Messages    []openAIMessage `json:"messages"`
+    MaxTokens   *int            `json:"max_tokens,omitempty"`
+    Temperature *float64        `json:"temperature,omitempty"`
+    TopP        *float64        `json:"top_p,omitempty"`
+    Stop        []string        `json:"stop,omitempty"`
+    Stream      bool            `json:"stream"`
+}
+
+// openAIDelta is a message or a streamed delta. Servers running reasoning models
+// report the reasoning as reasoning_content or reasoning.
+type openAIDelta struct {
+    Content          string `json:"content"`
+    ReasoningContent string `json:"reasoning_content"`
+    Reasoning        string `json:"reasoning"`
+}
+
+type openAIResponse struct {
+    Model   string `json:"model"`
+    Choices []struct {
+        Message openAIDelta `json:"message"`
+        Delta   openAIDelta `json:"delta"`
+    } `json:"choices"`
+    Error json.RawMessage `json:"error"`
+}
+
+func (p *OpenAI) Chat(ctx context.Context, req Request) (types.SiderParsedResponse, error) {
+    result := types.SiderParsedResponse{Model: req.Anthropic.Model, Attempts: 1}
+    ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+    defer cancel()
+
+    resp, err := p.post(ctx, req.Anthropic, false)
+    if err != nil {
+        return result, err
+    }
+    defer resp.Body.Close()
+
+    var parsed openAIResponse
+    if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+        return result, p.wrap(siderclient.ClassifyTransport(err), "decode response")
+    }
+    if len(parsed.Error) > 0 && string(parsed.Error) != "null" {
+        return result, p.wrap(&siderclient.UpstreamError{Kind: siderclient.KindStream, Message: errorMessage(parsed.Error)}, "")
+    }
+    if parsed.Model != "" {
+        result.Model = parsed.Model
+    }
+    if len(parsed.Choices) > 0 {
+        msg := parsed.Choices[0].Message
+        if r := msg.ReasoningContent + msg.Reasoning; r != "" {
+            result.ReasoningParts = append(result.ReasoningParts, r)
+        }
+        if msg.Content != "" {
+            result.TextParts = append(result.TextParts, msg.Content)
+        }
+    }
+    return result, nil
+}
+
+func (p *OpenAI) ChatStream(ctx context.Context, req Request, callback StreamCallback) (types.SiderParsedResponse, error) {
+    result := types.SiderParsedResponse{Model: req.Anthropic.Model, Attempts: 1}
+    if !p.Caps.Streaming {
+        return p.Chat(ctx, req)
+    }
+    ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+    defer cancel()
+
+    resp, err := p.post(ctx, req.Anthropic, true)
+    if err != nil {
+        return result, err
+    }
+    defer resp.Body.Close()
+
+    emit := func(data types.SiderResponseData) {
+        if callback != nil {
+            callback(types.SiderSSEResponse{Data: data}, result)
+        }
+    }
+    reader := siderclient.NewSSEReader(resp.Body, 0, 0)
+    for {
+        evt, err := reader.Next()
+        if errors.Is(err, io.EOF) {
+            return result, p.wrap(&siderclient.UpstreamError{Kind: siderclient.KindIncomplete, InStream: true, Message: "stream ended without [DONE]"}, "")
+        }
+        if err != nil {
+            return result, p.wrap(siderclient.ClassifyTransport(err), "read stream")
+        }
+        data := strings.TrimSpace(evt.Data)
+        if data == "" {
+            continue
+        }
+        if data == "[DONE]" {
+            return result, nil
+        }
+        var chunk openAIResponse
+        if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+            p.Logger.Debug("skipping undecodable stream event", "provider", p.ID, "data", data)
+            continue
+        }
+        if len(chunk.Error) > 0 && string(chunk.Error) != "null" {
+            return result, p.wrap(&siderclient.UpstreamError{Kind: siderclient.KindStream, InStream: true, Message: errorMessage(chunk.Error)}, "")
+        }
+        if chunk.Model != "" {
+            result.Model = chunk.Model
+        }
+        if len(chunk.Choices) == 0 {
+            continue
+        }
+        delta := chunk.Choices[0].Delta
+        if r := delta.ReasoningContent + delta.Reasoning; r != "" {
+            result.ReasoningParts = append(result.ReasoningParts, r)
+            emit(types.SiderResponseData{Type: "reasoning_content", Model: result.Model, ReasoningContent: &types.SiderReasoningContent{Status: "processing", Text: r}})
+        }
+        if delta.Content != "" {
+            result.TextParts = append(result.TextParts, delta.Content)
+            emit(types.SiderResponseData{Type: "text", Model: result.Model, Text: delta.Content})
+        }
+    }
+}
+
+// Models asks the server's /models endpoint, falling back to the configured models
+// when it has none.
+func (p *OpenAI) Models(ctx context.Context) ([]Model, error) {
+    ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+    defer cancel()
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.BaseURL+"/models", nil)
+    if err != nil {
+        return nil, err
+    }
+    p.authorize(req)
+    resp, err := p.HTTPClient.Do(req)
+    if err != nil {
+        return nil, p.wrap(siderclient.ClassifyTransport(err), "")
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+        return nil, p.responseError(resp.StatusCode, body)
+    }
+    var listed struct {
+        Data []struct {
+            ID string `json:"id"`
+        } `json:"data"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+        return nil, p.wrap(siderclient.ClassifyTransport(err), "decode models")
+    }
+    var out []Model
+    for _, m := range listed.Data {
+        out = append(out, Model{ID: m.ID, Provider: p.ID})
+    }
+    if len(out) == 0 {
+        for _, id := range p.Served {
+            out = append(out, Model{ID: id, Provider: p.ID})
+        }
+    }
+    return out, nil
+}
+
+func (p *OpenAI) post(ctx context.Context, req types.AnthropicRequest, stream bool) (*http.Response, error) {
+    body, err := json.Marshal(p.buildRequest(req, stream))
+    if err != nil {
+        return nil, fmt.Errorf("marshal request: %w", err)
+    }
+    httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(body))
+    if err != nil {
+        return nil, fmt.Errorf("build request: %w", err)
+    }
+    httpReq.Header.Set("Content-Type", "application/json")
+    if stream {
+        httpReq.Header.Set("Accept", "text/event-stream")
+    }
+    p.authorize(httpReq)
+
+    p.Logger.Debug("provider chat request", "provider", p.ID, "model", req.Model, "stream", stream)
+    resp, err := p.HTTPClient.Do(httpReq)
+    if err != nil {
+        return nil, p.wrap(siderclient.ClassifyTransport(err), "")
+    }
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        defer resp.Body.Close()
+        body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+        return nil, p.responseError(resp.StatusCode, body)
+    }
+    return resp, nil
+}
+
+func (p *OpenAI) authorize(req *http.Request) {
+    if p.APIKey != "" {
+        req.Header.Set("Authorization", "Bearer "+p.APIKey)
+    }
+}
+
+// buildRequest sends the whole Anthropic conversation; image blocks are only passed
+// on when the provider has vision.
+func (p *OpenAI) buildRequest(req types.AnthropicRequest, stream bool) openAIRequest {
+    out := openAIRequest{
+        Model:       req.Model,
+        MaxTokens:   req.MaxTokens,
+        Temperature: req.Temperature,
+        TopP:        req.TopP,
+        Stop:        req.StopSeq,
+        Stream:      stream,
+    }
+    if req.System != "" {
+        out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: req.System})
+    }
+    for _, m := range req.Messages {
+        out.Messages = append(out.Messages, openAIMessage{Role: m.Role, Content: openAIContent(m.Content, p.Caps.Vision)})
+    }
+    return out
+}
+
+// openAIContent converts Anthropic message content to a string, or to content parts
+// when images are kept.
+func openAIContent(content any, vision bool) any {
+    if s, ok := content.(string); ok {
+        return s
+    }
+    blocks, ok := content.([]types.AnthropicContent)
+    if !ok {
+        // decoded JSON arrives as []any
+        raw, _ := json.Marshal(content)
+        if json.Unmarshal(raw, &blocks) != nil {
+            return ""
+        }
+    }
+    hasImage := false
+    for _, b := range blocks {
+        if b.Type == "image" && b.Source != nil {
+            hasImage = true
+        }
+    }
+    if !vision || !hasImage {
+        var texts []string
+        for _, b := range blocks {
+            switch b.Type {
+            case "text":
+                texts = append(texts, b.Text)
+            case "tool_result":
+                texts = append(texts, converter.ExtractTextContent(b.Content))
+            }
+        }
+        return strings.Join(texts, "\n")
+    }
+    var parts []map[string]any
+    for _, b := range blocks {
+        switch {
+        case b.Type == "text":
+            parts = append(parts, map[string]any{"type": "text", "text": b.Text})
+        case b.Type == "image" && b.Source != nil:
+            url := "data:" + b.Source.MediaType + ";base64," + b.Source.Data
+            parts = append(parts, map[string]any{"type": "image_url", "image_url": map[string]any{"url": url}})
+        }
+    }
+    return parts
+}
+
+func (p *OpenAI) responseError(status int, body []byte) error {
+    ue := siderclient.ClassifyResponse(status, body)
+    var payload struct {
+        Error json.RawMessage `json:"error"`
+    }
+    if json.Unmarshal(body, &payload) == nil && len(payload.Error) > 0 {
+        ue.Message = errorMessage(payload.Error)
+    }
+    return p.wrap(ue, "")
+}
+
+// wrap names the provider in the error.
+func (p *OpenAI) wrap(ue *siderclient.UpstreamError, msg string) error {
+    ue.Upstream = p.ID
+    if msg != "" && ue.Message != "" {
+        ue.Message = msg + ": " + ue.Message
+    } else if msg != "" {
+        ue.Message = msg
+    }
+    return ue
+}
+
+// errorMessage reads the OpenAI error field, an object with a message or a string.
+func errorMessage(raw json.RawMessage) string {
+    var obj struct {
+        Message string `json:"message"`
+    }
+    if json.Unmarshal(raw, &obj) == nil && obj.Message != "" {
+        return obj.Message
+    }
+    var s string
+    if json.Unmarshal(raw, &s) == nil {
+        return s
+    }
+    return string(raw)
+}
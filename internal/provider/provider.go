@@ -0,0 +1,123 @@
+// Package provider puts the upstream model backends behind one interface, so the
+// Anthropic and OpenAI surfaces can front Sider and OpenAI-compatible servers alike.
+package provider
+
+import (
+    "context"
+    "strings"
+
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// Request is one chat turn. Anthropic is the canonical request (OpenAI requests are
+// bridged to it); Sider is the same turn converted with its conversation threading.
+// Token is the caller's Sider token, "" for the pool.
+type Request struct {
+    Anthropic types.AnthropicRequest
+    Sider     types.SiderRequest
+    Token     string
+}
+
+// StreamCallback receives each upstream event with the response so far. Providers
+// other than Sider report their deltas as Sider "text" and "reasoning_content" events.
+type StreamCallback = siderclient.StreamCallback
+
+// Capabilities describes what a provider supports for a model.
+type Capabilities struct {
+    Streaming bool `json:"streaming"`
+    Thinking  bool `json:"thinking"`
+    Vision    bool `json:"vision"`
+    WebSearch bool `json:"web_search"`
+    // Conversations is set when the upstream keeps conversation state, so turns are
+    // threaded by conversation id instead of resending the history.
+    Conversations bool `json:"conversations"`
+}
+
+// Model is one model a provider serves.
+type Model struct {
+    ID       string `json:"id"`
+    Provider string `json:"provider"`
+}
+
+// Provider is an upstream model backend. Failures are *siderclient.UpstreamError so
+// the handlers map them the same way whichever provider answered.
+type Provider interface {
+    Name() string
+    Chat(ctx context.Context, req Request) (types.SiderParsedResponse, error)
+    ChatStream(ctx context.Context, req Request, callback StreamCallback) (types.SiderParsedResponse, error)
+    Models(ctx context.Context) ([]Model, error)
+    Capabilities(model string) Capabilities
+}
+
+// Router selects the provider for a model. Routes come first, then the models
+// claimed by providers; anything else goes to the default provider.
+type Router struct {
+    Default   Provider
+    providers []Provider
+    byName    map[string]Provider
+    byModel   map[string]Provider
+    routes    map[string]*Route
+    // names are the configured model names and route aliases, as written.
+    names []string
+}
+
+// NewRouter returns a router that sends every model to def.
+func NewRouter(def Provider) *Router {
+    return &Router{
+        Default:   def,
+        providers: []Provider{def},
+        byName:    map[string]Provider{def.Name(): def},
+        byModel:   map[string]Provider{},
+        routes:    map[string]*Route{},
+    }
+}
+
+// Add registers p for models. Model names match case-insensitively.
+func (r *Router) Add(p Provider, models []string) {
+    r.providers = append(r.providers, p)
+    r.byName[p.Name()] = p
+    for _, m := range models {
+        r.byModel[strings.ToLower(m)] = p
+        r.names = append(r.names, m)
+    }
+}
+
+// AddRoute registers a fallback route for its alias.
+func (r *Router) AddRoute(route *Route) {
+    r.routes[strings.ToLower(route.Alias)] = route
+    r.names = append(r.names, route.Alias)
+}
+
+// Names returns the models assigned to providers and the route aliases, in the
+// order they were configured.
+func (r *Router) Names() []string {
+    return append([]string(nil), r.names...)
+}
+
+// Provider returns the provider registered under name.
+func (r *Router) Provider(name string) (Provider, bool) {
+    p, ok := r.byName[name]
+    return p, ok
+}
+
+// For returns the provider serving model; a routed alias gets its *Route.
+func (r *Router) For(model string) Provider {
+    if route, ok := r.routes[strings.ToLower(model)]; ok {
+        return route
+    }
+    return r.direct(model)
+}
+
+// direct returns the provider serving model, ignoring routes.
+func (r *Router) direct(model string) Provider {
+    if p, ok := r.byModel[strings.ToLower(model)]; ok {
+        return p
+    }
+    return r.Default
+}
+
+// Providers returns every registered provider, the default first.
+func (r *Router) Providers() []Provider {
+    return append([]Provider(nil), r.providers...)
+}
@@ -0,0 +1,143 @@
+package provider
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "slices"
+    "sync/atomic"
+    "time"
+
+    "sider2api/internal/models"
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// DefaultFallbackOn are the failures that move a route on to its next target: the
+// model or upstream cannot answer right now, but another one might.
+var DefaultFallbackOn = []siderclient.ErrorKind{
+    siderclient.KindModelUnavailable,
+    siderclient.KindRateLimit,
+    siderclient.KindQuotaExhausted,
+    siderclient.KindUnavailable,
+    siderclient.KindTimeout,
+    siderclient.KindCircuitOpen,
+}
+
+// Target is one step of a route: a provider and the model to ask it for.
+type Target struct {
+    Provider Provider
+    Model    string
+}
+
+// Route serves a model alias from an ordered list of targets. A target that fails
+// with one of the FallbackOn kinds, or has not started answering within
+// FirstEventTimeout, is abandoned for the next one. Nothing falls back once the
+// target has sent content, since the client may already have part of the answer;
+// events before that, such as message_start, reach no client.
+type Route struct {
+    Alias             string
+    Targets           []Target
+    FallbackOn        []siderclient.ErrorKind
+    FirstEventTimeout time.Duration
+    // Registry maps target models to their Sider names.
+    Registry *models.Registry
+    Logger   *slog.Logger
+}
+
+func (r *Route) Name() string { return "route:" + r.Alias }
+
+// Capabilities are those of the first target.
+func (r *Route) Capabilities(model string) Capabilities {
+    t := r.Targets[0]
+    return t.Provider.Capabilities(t.Model)
+}
+
+func (r *Route) Models(ctx context.Context) ([]Model, error) {
+    return []Model{{ID: r.Alias, Provider: r.Name()}}, nil
+}
+
+func (r *Route) Chat(ctx context.Context, req Request) (types.SiderParsedResponse, error) {
+    return r.ChatStream(ctx, req, nil)
+}
+
+// ChatStream tries the targets in order. The result's Model is the target model
+// that answered.
+func (r *Route) ChatStream(ctx context.Context, req Request, callback StreamCallback) (types.SiderParsedResponse, error) {
+    var (
+        result types.SiderParsedResponse
+        err    error
+    )
+    for i, t := range r.Targets {
+        var started atomic.Bool
+        forward := func(evt types.SiderSSEResponse, partial types.SiderParsedResponse) {
+            if isContent(evt) {
+                started.Store(true)
+            }
+            partial.Model = t.Model
+            if callback != nil {
+                callback(evt, partial)
+            }
+        }
+
+        attemptCtx, cancel := context.WithCancel(ctx)
+        var slow atomic.Bool
+        var timer *time.Timer
+        if r.FirstEventTimeout > 0 {
+            timer = time.AfterFunc(r.FirstEventTimeout, func() {
+                if !started.Load() {
+                    slow.Store(true)
+                    cancel()
+                }
+            })
+        }
+        result, err = t.Provider.ChatStream(attemptCtx, retarget(req, t.Model, r.Registry), forward)
+        if timer != nil {
+            timer.Stop()
+        }
+        cancel()
+        result.Model = t.Model
+        if slow.Load() && ctx.Err() == nil {
+            err = &siderclient.UpstreamError{Kind: siderclient.KindTimeout, Upstream: t.Provider.Name(), Message: fmt.Sprintf("no response from %s within %s", t.Model, r.FirstEventTimeout)}
+        }
+        if err == nil {
+            if i > 0 {
+                r.Logger.Info("route answered by fallback", "alias", r.Alias, "provider", t.Provider.Name(), "model", t.Model)
+            }
+            return result, nil
+        }
+
+        last := i == len(r.Targets)-1
+        if last || started.Load() || ctx.Err() != nil || !(slow.Load() || slices.Contains(r.FallbackOn, siderclient.KindOf(err))) {
+            return result, err
+        }
+        next := r.Targets[i+1]
+        r.Logger.Warn("route target failed, falling back",
+            "alias", r.Alias,
+            "provider", t.Provider.Name(),
+            "model", t.Model,
+            "kind", siderclient.KindOf(err).String(),
+            "next_provider", next.Provider.Name(),
+            "next_model", next.Model,
+            "error", err,
+        )
+    }
+    return result, err
+}
+
+// isContent reports whether evt carries part of the answer: a text, reasoning or
+// tool delta.
+func isContent(evt types.SiderSSEResponse) bool {
+    switch evt.Data.Type {
+    case "text", "reasoning_content", "tool_call", "tool_call_start", "tool_call_progress", "tool_call_result":
+        return true
+    }
+    return false
+}
+
+// retarget points req at model for both the Sider and the canonical request.
+func retarget(req Request, model string, registry *models.Registry) Request {
+    req.Anthropic.Model = model
+    req.Sider.Model = registry.UpstreamName(model)
+    return req
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+    "context"
+    "io"
+    "log/slog"
+    "testing"
+    "time"
+
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// stubProvider answers with a fixed list of events followed by err. A zero stall
+// answers at once; otherwise it waits that long (or until ctx ends) after the events.
+type stubProvider struct {
+    name   string
+    events []types.SiderResponseData
+    stall  time.Duration
+    err    error
+    calls  int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Chat(ctx context.Context, req Request) (types.SiderParsedResponse, error) {
+    return p.ChatStream(ctx, req, nil)
+}
+
+func (p *stubProvider) ChatStream(ctx context.Context, req Request, callback StreamCallback) (types.SiderParsedResponse, error) {
+    p.calls++
+    var result types.SiderParsedResponse
+    for _, data := range p.events {
+        if data.Type == "text" {
+            result.TextParts = append(result.TextParts, data.Text)
+        }
+        if callback != nil {
+            callback(types.SiderSSEResponse{Data: data}, result)
+        }
+    }
+    if p.stall > 0 {
+        select {
+        case <-time.After(p.stall):
+        case <-ctx.Done():
+            return result, &siderclient.UpstreamError{Kind: siderclient.KindCanceled, Err: ctx.Err()}
+        }
+    }
+    return result, p.err
+}
+
+func (p *stubProvider) Models(ctx context.Context) ([]Model, error) { return nil, nil }
+
+func (p *stubProvider) Capabilities(model string) Capabilities { return Capabilities{Streaming: true} }
+
+var (
+    messageStart = types.SiderResponseData{Type: "message_start", MessageStart: &types.SiderMessageStart{CID: "c1"}}
+    textDelta    = types.SiderResponseData{Type: "text", Text: "partial"}
+)
+
+func TestRouteFallback(t *testing.T) {
+    unavailable := &siderclient.UpstreamError{Kind: siderclient.KindModelUnavailable, Message: "model unavailable"}
+    badRequest := &siderclient.UpstreamError{Kind: siderclient.KindBadRequest, Message: "bad request"}
+    tests := []struct {
+        name       string
+        first      *stubProvider
+        wantModel  string
+        wantKind   siderclient.ErrorKind
+        wantSecond int
+    }{
+        {
+            name:      "first target answers",
+            first:     &stubProvider{events: []types.SiderResponseData{messageStart, textDelta}},
+            wantModel: "first-model",
+        },
+        {
+            name:       "error before any event",
+            first:      &stubProvider{err: unavailable},
+            wantModel:  "second-model",
+            wantSecond: 1,
+        },
+        {
+            name:       "error after message_start",
+            first:      &stubProvider{events: []types.SiderResponseData{messageStart}, err: unavailable},
+            wantModel:  "second-model",
+            wantSecond: 1,
+        },
+        {
+            name:      "error after content stays with the target",
+            first:     &stubProvider{events: []types.SiderResponseData{messageStart, textDelta}, err: unavailable},
+            wantModel: "first-model",
+            wantKind:  siderclient.KindModelUnavailable,
+        },
+        {
+            name:      "error kind outside fallback_on",
+            first:     &stubProvider{events: []types.SiderResponseData{messageStart}, err: badRequest},
+            wantModel: "first-model",
+            wantKind:  siderclient.KindBadRequest,
+        },
+        {
+            name:       "stall after message_start times out",
+            first:      &stubProvider{events: []types.SiderResponseData{messageStart}, stall: time.Second},
+            wantModel:  "second-model",
+            wantSecond: 1,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tt.first.name = "first"
+            second := &stubProvider{name: "second", events: []types.SiderResponseData{textDelta}}
+            r := &Route{
+                Alias:             "alias",
+                Targets:           []Target{{Provider: tt.first, Model: "first-model"}, {Provider: second, Model: "second-model"}},
+                FallbackOn:        DefaultFallbackOn,
+                FirstEventTimeout: 50 * time.Millisecond,
+                Logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+            }
+
+            var seen []string
+            resp, err := r.ChatStream(context.Background(), Request{}, func(evt types.SiderSSEResponse, partial types.SiderParsedResponse) {
+                seen = append(seen, partial.Model)
+            })
+            if siderclient.KindOf(err) != tt.wantKind {
+                t.Fatalf("err = %v, want kind %v", err, tt.wantKind)
+            }
+            if resp.Model != tt.wantModel {
+                t.Errorf("model = %q, want %q", resp.Model, tt.wantModel)
+            }
+            if second.calls != tt.wantSecond {
+                t.Errorf("second target called %d times, want %d", second.calls, tt.wantSecond)
+            }
+            if len(seen) == 0 || seen[len(seen)-1] != tt.wantModel {
+                t.Errorf("callback models = %v, want the last from %q", seen, tt.wantModel)
+            }
+        })
+    }
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+    "context"
+
+    "sider2api/internal/models"
+    "sider2api/internal/siderclient"
+    "sider2api/pkg/types"
+)
+
+// Sider is the provider backed by the Sider API.
+type Sider struct {
+    Client *siderclient.Client
+    // Registry lists the Sider models and their capabilities; nil uses the built-in one.
+    Registry *models.Registry
+}
+
+func (s *Sider) Name() string { return "sider" }
+
+func (s *Sider) Chat(ctx context.Context, req Request) (types.SiderParsedResponse, error) {
+    return s.Client.Chat(ctx, req.Sider, req.Token)
+}
+
+func (s *Sider) ChatStream(ctx context.Context, req Request, callback StreamCallback) (types.SiderParsedResponse, error) {
+    return s.Client.ChatStream(ctx, req.Sider, req.Token, callback)
+}
+
+// Models lists the registry; Sider has no listing endpoint.
+func (s *Sider) Models(ctx context.Context) ([]Model, error) {
+    var out []Model
+    for _, id := range s.Registry.IDs() {
+        out = append(out, Model{ID: id, Provider: s.Name()})
+    }
+    return out, nil
+}
+
+// Capabilities come from the registry. Models it does not know are assumed to
+// support thinking and search, which Sider offers for most models.
+func (s *Sider) Capabilities(model string) Capabilities {
+    caps := Capabilities{Streaming: true, Thinking: true, WebSearch: true, Conversations: true}
+    if m, ok := s.Registry.Lookup(model); ok {
+        caps.Thinking = m.SupportsThink
+        caps.WebSearch = m.SupportsSearch
+        caps.Vision = m.SupportsVision
+    }
+    return caps
+}
@@ -0,0 +1,247 @@
+package ratelimit
+
+import (
+    "fmt"
+    "math"
+    "sync"
+    "time"
+)
+
+// Limits caps one scope. Zero fields are unlimited.
+type Limits struct {
+    RPM         int // requests per minute
+    TPM         int // tokens per minute
+    Concurrency int // concurrent streams
+}
+
+// Merge returns l with zero fields filled from def.
+func (l Limits) Merge(def Limits) Limits {
+    if l.RPM == 0 {
+        l.RPM = def.RPM
+    }
+    if l.TPM == 0 {
+        l.TPM = def.TPM
+    }
+    if l.Concurrency == 0 {
+        l.Concurrency = def.Concurrency
+    }
+    return l
+}
+
+func (l Limits) enabled() bool {
+    return l.RPM > 0 || l.TPM > 0 || l.Concurrency > 0
+}
+
+// Status is the remaining budget of one scope, used for rate limit headers.
+type Status struct {
+    RequestsLimit     int
+    RequestsRemaining int
+    RequestsReset     time.Duration
+    TokensLimit       int
+    TokensRemaining   int
+    TokensReset       time.Duration
+}
+
+// LimitError describes a throttled request.
+type LimitError struct {
+    Scope      string // "client" or "global"
+    Reason     string // "requests", "tokens" or "concurrency"
+    Limit      int
+    RetryAfter time.Duration
+    Status     Status
+}
+
+func (e *LimitError) Error() string {
+    unit := map[string]string{"requests": "requests per minute", "tokens": "tokens per minute", "concurrency": "concurrent streams"}[e.Reason]
+    return fmt.Sprintf("rate limit exceeded: %s limit of %d %s; retry after %s", e.Scope, e.Limit, unit, e.RetryAfter.Round(time.Second))
+}
+
+// bucket refills to capacity over one minute.
+type bucket struct {
+    capacity float64
+    level    float64
+    last     time.Time
+}
+
+func newBucket(capacity int, now time.Time) *bucket {
+    return &bucket{capacity: float64(capacity), level: float64(capacity), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+    rate := b.capacity / 60
+    b.level = math.Min(b.capacity, b.level+now.Sub(b.last).Seconds()*rate)
+    b.last = now
+}
+
+// wait is how long until the bucket holds n.
+func (b *bucket) wait(n float64) time.Duration {
+    if b.level >= n {
+        return 0
+    }
+    return time.Duration((n - b.level) / (b.capacity / 60) * float64(time.Second))
+}
+
+func (b *bucket) remaining() int {
+    return int(math.Max(0, math.Floor(b.level)))
+}
+
+type scope struct {
+    name     string
+    limits   Limits
+    requests *bucket
+    tokens   *bucket
+    inFlight int
+    lastUsed time.Time
+}
+
+func newScope(name string, l Limits, now time.Time) *scope {
+    s := &scope{name: name, limits: l, lastUsed: now}
+    if l.RPM > 0 {
+        s.requests = newBucket(l.RPM, now)
+    }
+    if l.TPM > 0 {
+        s.tokens = newBucket(l.TPM, now)
+    }
+    return s
+}
+
+func (s *scope) check(now time.Time, tokens int, stream bool) *LimitError {
+    if s.requests != nil {
+        s.requests.refill(now)
+        if d := s.requests.wait(1); d > 0 {
+            return &LimitError{Scope: s.name, Reason: "requests", Limit: s.limits.RPM, RetryAfter: d}
+        }
+    }
+    if s.tokens != nil {
+        s.tokens.refill(now)
+        need := math.Min(float64(tokens), s.tokens.capacity)
+        if d := s.tokens.wait(math.Max(need, 1)); d > 0 {
+            return &LimitError{Scope: s.name, Reason: "tokens", Limit: s.limits.TPM, RetryAfter: d}
+        }
+    }
+    if stream && s.limits.Concurrency > 0 && s.inFlight >= s.limits.Concurrency {
+        return &LimitError{Scope: s.name, Reason: "concurrency", Limit: s.limits.Concurrency, RetryAfter: time.Second}
+    }
+    return nil
+}
+
+func (s *scope) status() Status {
+    var st Status
+    if s.requests != nil {
+        st.RequestsLimit = s.limits.RPM
+        st.RequestsRemaining = s.requests.remaining()
+        st.RequestsReset = s.requests.wait(s.requests.capacity)
+    }
+    if s.tokens != nil {
+        st.TokensLimit = s.limits.TPM
+        st.TokensRemaining = s.tokens.remaining()
+        st.TokensReset = s.tokens.wait(s.tokens.capacity)
+    }
+    return st
+}
+
+// Limiter enforces per-client and global request, token and stream limits with
+// token buckets that refill continuously over a minute.
+type Limiter struct {
+    mu      sync.Mutex
+    global  *scope
+    perKey  Limits
+    clients map[string]*scope
+}
+
+// New returns a limiter with global limits and default per-client limits.
+func New(global, perKey Limits) *Limiter {
+    l := &Limiter{perKey: perKey, clients: map[string]*scope{}}
+    if global.enabled() {
+        l.global = newScope("global", global, time.Now())
+    }
+    return l
+}
+
+// Ticket is an admitted request. Done must be called when it finishes.
+type Ticket struct {
+    // Status is the budget left on the most specific limited scope.
+    Status Status
+
+    l      *Limiter
+    scopes []*scope
+    tokens int
+    stream bool
+    done   bool
+}
+
+// Acquire admits a request from client, charging the estimated tokens up front.
+// override replaces the default per-client limits field by field.
+func (l *Limiter) Acquire(client string, override Limits, tokens int, stream bool) (*Ticket, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := time.Now()
+
+    var scopes []*scope
+    if lim := override.Merge(l.perKey); lim.enabled() {
+        s := l.clients[client]
+        if s == nil || s.limits != lim {
+            s = newScope("client", lim, now)
+            l.clients[client] = s
+            l.prune(now)
+        }
+        scopes = append(scopes, s)
+    }
+    if l.global != nil {
+        scopes = append(scopes, l.global)
+    }
+
+    for _, s := range scopes {
+        if err := s.check(now, tokens, stream); err != nil {
+            err.Status = s.status()
+            return nil, err
+        }
+    }
+    t := &Ticket{l: l, scopes: scopes, tokens: tokens, stream: stream}
+    for _, s := range scopes {
+        if s.requests != nil {
+            s.requests.level--
+        }
+        if s.tokens != nil {
+            s.tokens.level -= float64(tokens)
+        }
+        if stream {
+            s.inFlight++
+        }
+        s.lastUsed = now
+    }
+    if len(scopes) > 0 {
+        t.Status = scopes[0].status()
+    }
+    return t, nil
+}
+
+// Done settles the token charge with the actual usage and frees the stream slot.
+func (t *Ticket) Done(actualTokens int) {
+    t.l.mu.Lock()
+    defer t.l.mu.Unlock()
+    if t.done {
+        return
+    }
+    t.done = true
+    for _, s := range t.scopes {
+        if s.tokens != nil {
+            s.tokens.level -= float64(actualTokens - t.tokens)
+        }
+        if t.stream && s.inFlight > 0 {
+            s.inFlight--
+        }
+    }
+}
+
+// prune drops idle client scopes whose buckets have refilled.
+func (l *Limiter) prune(now time.Time) {
+    if len(l.clients) < 1024 {
+        return
+    }
+    for k, s := range l.clients {
+        if s.inFlight == 0 && now.Sub(s.lastUsed) > 2*time.Minute {
+            delete(l.clients, k)
+        }
+    }
+}
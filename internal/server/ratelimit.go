@@ -0,0 +1,150 @@
+package server
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io"
+    "log/slog"
+    "math"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/apikeys"
+    "sider2api/internal/config"
+    "sider2api/internal/ratelimit"
+    "sider2api/internal/session"
+    "sider2api/pkg/types"
+)
+
+// isOpenAIPath reports whether errors on path use the OpenAI format.
+func isOpenAIPath(path string) bool {
+    return strings.HasPrefix(path, "/v1/chat/completions") || strings.HasPrefix(path, "/v1/responses")
+}
+
+// maxPeekBody bounds how much of a request body is read to estimate tokens.
+const maxPeekBody = 8 << 20
+
+// newLimiter builds the limiter from the per-client and global settings.
+func newLimiter(cfg config.Config) *ratelimit.Limiter {
+    return ratelimit.New(
+        ratelimit.Limits{RPM: cfg.GlobalRPM, TPM: cfg.GlobalTPM, Concurrency: cfg.GlobalConcurrency},
+        ratelimit.Limits{RPM: cfg.RateLimitRPM, TPM: cfg.RateLimitTPM, Concurrency: cfg.RateLimitConcurrency},
+    )
+}
+
+// RateLimitMiddleware throttles upstream-bound requests per client and globally.
+// It must run after AuthMiddleware, which identifies the client.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, logger *slog.Logger) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        probe := probeRequest(c)
+        client, key := clientIdentity(c)
+        override := ratelimit.Limits{RPM: key.RPM, TPM: key.TPM, Concurrency: key.Concurrency}
+        ticket, err := limiter.Acquire(client, override, probe.EstimatedTokens, probe.Stream)
+        if err != nil {
+            var le *ratelimit.LimitError
+            if !errors.As(err, &le) {
+                logger.Error("rate limiter failed", "client", client, "error", err)
+                c.AbortWithStatus(http.StatusInternalServerError)
+                return
+            }
+            setRateLimitHeaders(c, le.Status)
+            c.Header("Retry-After", strconv.Itoa(int(math.Ceil(le.RetryAfter.Seconds()))))
+            logger.Warn("rate limited", "client", client, "scope", le.Scope, "reason", le.Reason, "retry_after", le.RetryAfter)
+            if isOpenAIPath(c.Request.URL.Path) {
+                c.AbortWithStatusJSON(http.StatusTooManyRequests, types.OpenAIErrorResponse{Error: types.OpenAIError{Message: le.Error(), Type: "rate_limit_error", Code: "rate_limit_exceeded"}})
+            } else {
+                c.AbortWithStatusJSON(http.StatusTooManyRequests, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "rate_limit_error", Message: le.Error()}})
+            }
+            return
+        }
+        setRateLimitHeaders(c, ticket.Status)
+
+        c.Next()
+
+        in, out := requestTokens(c, probe)
+        ticket.Done(in + out)
+    }
+}
+
+// requestProbe is what the middlewares need from a request body before the handler runs.
+type requestProbe struct {
+    Model           string `json:"model"`
+    Stream          bool   `json:"stream"`
+    EstimatedTokens int    `json:"-"`
+}
+
+// probeRequest reads the request body once, restores it for the handler and caches
+// the result on the context.
+func probeRequest(c *gin.Context) requestProbe {
+    if v, ok := c.Get("requestProbe"); ok {
+        return v.(requestProbe)
+    }
+    var probe requestProbe
+    if c.Request.Body != nil {
+        // only the peeked prefix is buffered; the rest still streams from the client
+        body := c.Request.Body
+        data, _ := io.ReadAll(io.LimitReader(body, maxPeekBody))
+        c.Request.Body = struct {
+            io.Reader
+            io.Closer
+        }{io.MultiReader(bytes.NewReader(data), body), body}
+        _ = json.Unmarshal(data, &probe)
+        probe.EstimatedTokens = len(data) / 4
+    }
+    if probe.Model == "" {
+        probe.Model = c.Query("model")
+    }
+    c.Set("requestProbe", probe)
+    return probe
+}
+
+// requestTokens returns the input and output tokens of a finished request, using
+// the body estimate when the handler reported less input.
+func requestTokens(c *gin.Context, probe requestProbe) (int, int) {
+    if v, ok := c.Get("usage"); ok {
+        if u, ok := v.(types.AnthropicUsage); ok {
+            return max(probe.EstimatedTokens, u.InputTokens), u.OutputTokens
+        }
+    }
+    return probe.EstimatedTokens, 0
+}
+
+// clientIdentity identifies the caller: its proxy key, else a hash of its Sider
+// token, else its IP.
+func clientIdentity(c *gin.Context) (string, apikeys.Key) {
+    if v, ok := c.Get("apiKey"); ok {
+        if key, ok := v.(apikeys.Key); ok {
+            return "key:" + key.ID, key
+        }
+    }
+    if token := c.GetString("authToken"); token != "" {
+        return "token:" + session.OwnerKey(token), apikeys.Key{}
+    }
+    return "ip:" + c.ClientIP(), apikeys.Key{}
+}
+
+// setRateLimitHeaders emits both the Anthropic and OpenAI rate limit headers.
+func setRateLimitHeaders(c *gin.Context, st ratelimit.Status) {
+    now := time.Now().UTC()
+    if st.RequestsLimit > 0 {
+        c.Header("anthropic-ratelimit-requests-limit", strconv.Itoa(st.RequestsLimit))
+        c.Header("anthropic-ratelimit-requests-remaining", strconv.Itoa(st.RequestsRemaining))
+        c.Header("anthropic-ratelimit-requests-reset", now.Add(st.RequestsReset).Format(time.RFC3339))
+        c.Header("x-ratelimit-limit-requests", strconv.Itoa(st.RequestsLimit))
+        c.Header("x-ratelimit-remaining-requests", strconv.Itoa(st.RequestsRemaining))
+        c.Header("x-ratelimit-reset-requests", st.RequestsReset.Round(time.Millisecond).String())
+    }
+    if st.TokensLimit > 0 {
+        c.Header("anthropic-ratelimit-tokens-limit", strconv.Itoa(st.TokensLimit))
+        c.Header("anthropic-ratelimit-tokens-remaining", strconv.Itoa(st.TokensRemaining))
+        c.Header("anthropic-ratelimit-tokens-reset", now.Add(st.TokensReset).Format(time.RFC3339))
+        c.Header("x-ratelimit-limit-tokens", strconv.Itoa(st.TokensLimit))
+        c.Header("x-ratelimit-remaining-tokens", strconv.Itoa(st.TokensRemaining))
+        c.Header("x-ratelimit-reset-tokens", st.TokensReset.Round(time.Millisecond).String())
+    }
+}
@@ -1,19 +1,26 @@
 package server
 
 import (
+    "context"
+    "crypto/subtle"
     "fmt"
     "log/slog"
     "net/http"
+    "os"
     "strings"
     "time"
 
     "github.com/gin-contrib/cors"
     "github.com/gin-gonic/gin"
 
+    "sider2api/internal/apikeys"
     "sider2api/internal/config"
     "sider2api/internal/handlers"
+    "sider2api/internal/models"
+    "sider2api/internal/provider"
     "sider2api/internal/session"
     "sider2api/internal/siderclient"
+    "sider2api/internal/usage"
 )
 
 // Server wraps the Gin engine and dependencies.
@@ -22,10 +29,11 @@ type Server struct {
     Handler  *handlers.Handler
     Sessions *session.SiderSessionManager
     Client   *siderclient.Client
+    Usage    *usage.Ledger
 }
 
 // New constructs a configured Gin server with routes and middleware.
-func New(cfg config.Config, logger *slog.Logger) *Server {
+func New(cfg config.Config, logger *slog.Logger) (*Server, error) {
     gin.SetMode(gin.ReleaseMode)
     r := gin.New()
 
@@ -35,15 +43,62 @@ func New(cfg config.Config, logger *slog.Logger) *Server {
     r.Use(cors.New(cors.Config{
         AllowAllOrigins:  true,
         AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-        AllowHeaders:     []string{"Content-Type", "Authorization", "X-Conversation-ID", "X-Parent-Message-ID"},
-        ExposeHeaders:    []string{"X-Conversation-ID", "X-Assistant-Message-ID", "X-User-Message-ID"},
+        AllowHeaders:     []string{"Content-Type", "Authorization", "X-API-Key", "X-Conversation-ID", "X-Parent-Message-ID", "X-Time-Zone", "anthropic-version"},
+        ExposeHeaders:    []string{"X-Conversation-ID", "X-Assistant-Message-ID", "X-User-Message-ID", "X-Sider2api-Retries", "X-Sider2api-Upstream-Model", "X-Sider2api-Warnings", "Retry-After"},
         AllowCredentials: false,
         MaxAge:           12 * time.Hour,
     }))
 
-    sessions := session.NewSiderSessionManager(cfg.SiderSessionMaxAge, cfg.ContinuousCID)
-    client := siderclient.New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sessions)
+    keys, err := apikeys.Open(cfg.APIKeysFile)
+    if err != nil {
+        return nil, fmt.Errorf("api keys: %w", err)
+    }
+    if cfg.AuthMode, err = resolveAuthMode(cfg, logger); err != nil {
+        return nil, err
+    }
+
+    sessions, err := session.NewFromConfig(cfg, logger)
+    if err != nil {
+        return nil, fmt.Errorf("session store: %w", err)
+    }
+    client, err := siderclient.NewFromConfig(cfg, sessions, logger)
+    if err != nil {
+        sessions.Close()
+        return nil, fmt.Errorf("sider client: %w", err)
+    }
+    if client.Pool != nil {
+        logger.Info("sider token pool enabled", "tokens", client.Pool.Size(), "strategy", client.Pool.Strategy(), "cooldown", cfg.TokenCooldown)
+    }
+    registry, err := models.Load(cfg.ModelsFile)
+    if err != nil {
+        sessions.Close()
+        return nil, fmt.Errorf("models: %w", err)
+    }
+    providers, err := provider.FromConfig(cfg, client, registry, logger)
+    if err != nil {
+        sessions.Close()
+        return nil, fmt.Errorf("providers: %w", err)
+    }
+    ledger, err := usage.Open(cfg.UsageLedgerPath)
+    if err != nil {
+        sessions.Close()
+        return nil, fmt.Errorf("usage ledger: %w", err)
+    }
     handler := handlers.New(cfg, client, sessions, logger)
+    handler.Usage = ledger
+    handler.Models = registry
+    handler.Providers = providers
+    if cfg.ModelsURL != "" {
+        // with a pool configured the client picks the token
+        token := cfg.SiderAPIToken
+        if cfg.HasTokenPool() {
+            token = ""
+        }
+        handler.Discovery = models.NewDiscovery(registry, cfg.ModelsTTL, func(ctx context.Context) ([]models.Model, error) {
+            return client.FetchModels(ctx, token)
+        }, logger)
+        logger.Info("model discovery enabled", "url", cfg.ModelsURL, "ttl", cfg.ModelsTTL)
+    }
 
     // public routes
     r.GET("/health", handler.Health)
@@ -58,12 +113,33 @@ func New(cfg config.Config, logger *slog.Logger) *Server {
 
     // authenticated routes
     authGroup := r.Group("/")
-    authGroup.Use(AuthMiddleware(cfg, logger))
-    authGroup.POST("/v1/messages", handler.PostMessages)
+    authGroup.Use(AuthMiddleware(cfg, keys, logger))
+    // only routes that reach Sider are metered and rate limited
+    track := UsageMiddleware(ledger, defaultQuota(cfg), logger)
+    limit := RateLimitMiddleware(newLimiter(cfg), logger)
+    authGroup.POST("/v1/messages", track, limit, handler.PostMessages)
     authGroup.POST("/v1/messages/count_tokens", handler.CountTokens)
-    authGroup.POST("/v1/chat/completions", handler.PostChatCompletions)
+    authGroup.GET("/v1/models", handler.ListModels)
+    authGroup.GET("/v1/models/:id", handler.GetModel)
+    authGroup.POST("/v1/chat/completions", track, limit, handler.PostChatCompletions)
+    authGroup.POST("/v1/responses", track, limit, handler.PostResponses)
+    authGroup.GET("/v1/sider/conversations/:cid/messages", track, limit, handler.GetConversationMessages)
+
+    // admin routes use their own token and are only mounted when one is configured
+    if cfg.AdminToken != "" {
+        admin := r.Group("/admin")
+        admin.Use(AdminMiddleware(cfg, logger))
+        admin.GET("/sessions", handler.AdminListSessions)
+        admin.POST("/sessions/cleanup", handler.AdminCleanupSessions)
+        admin.GET("/sessions/:cid", handler.AdminGetSession)
+        admin.DELETE("/sessions/:cid", handler.AdminDeleteSession)
+        admin.GET("/tokens", handler.AdminListTokens)
+        admin.GET("/usage", handler.AdminUsage)
+    } else {
+        logger.Info("admin API disabled; set ADMIN_TOKEN to enable /admin")
+    }
 
-    return &Server{Engine: r, Handler: handler, Sessions: sessions, Client: client}
+    return &Server{Engine: r, Handler: handler, Sessions: sessions, Client: client, Usage: ledger}, nil
 }
 
 // Run starts the HTTP server.
@@ -72,8 +148,19 @@ func (s *Server) Run(host string, port int) error {
     return s.Engine.Run(addr)
 }
 
-// AuthMiddleware enforces Bearer auth, allowing env token or dummy token when configured.
-func AuthMiddleware(cfg config.Config, logger *slog.Logger) gin.HandlerFunc {
+// Close releases resources held by the server's dependencies.
+func (s *Server) Close() error {
+    if err := s.Usage.Close(); err != nil {
+        s.Sessions.Close()
+        return err
+    }
+    return s.Sessions.Close()
+}
+
+// AuthMiddleware enforces Bearer auth. Proxy API keys (s2a-…) are resolved to their
+// Sider token; otherwise, in passthrough mode, the bearer is a Sider token and the env
+// token or dummy token is allowed when configured.
+func AuthMiddleware(cfg config.Config, keys *apikeys.Store, logger *slog.Logger) gin.HandlerFunc {
     return func(c *gin.Context) {
         token := ""
         authHeader := c.GetHeader("Authorization")
@@ -84,6 +171,39 @@ func AuthMiddleware(cfg config.Config, logger *slog.Logger) gin.HandlerFunc {
                 return
             }
             token = t
+        } else if k := c.GetHeader("X-API-Key"); apikeys.IsProxyKey(k) {
+            token = k
+        }
+
+        if apikeys.IsProxyKey(token) {
+            key, err := keys.Authenticate(token)
+            if err != nil {
+                logger.Warn("rejected API key", "key_id", key.ID, "path", c.Request.URL.Path, "remote", c.ClientIP(), "error", err)
+                c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": gin.H{"type": "authentication_error", "message": err.Error()}})
+                return
+            }
+            siderToken, ok := siderTokenForKey(cfg, key)
+            if !ok {
+                logger.Error("API key has no usable Sider token", "key_id", key.ID)
+                c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": gin.H{"type": "api_error", "message": "no Sider token configured for this API key"}})
+                return
+            }
+            c.Set("apiKey", key)
+            c.Set("authToken", siderToken)
+            c.Next()
+            return
+        }
+
+        if cfg.AuthMode == "keys" {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": gin.H{"type": "authentication_error", "message": "a proxy API key is required"}})
+            return
+        }
+
+        // an empty token tells the client to draw one from the pool
+        if token == "" && cfg.UseEnvToken && cfg.HasTokenPool() {
+            c.Set("authToken", "")
+            c.Next()
+            return
         }
 
         if token == "" && cfg.UseEnvToken && cfg.SiderAPIToken != "" {
@@ -105,6 +225,52 @@ func AuthMiddleware(cfg config.Config, logger *slog.Logger) gin.HandlerFunc {
     }
 }
 
+// resolveAuthMode turns "auto" into keys when the keys file exists and passthrough
+// otherwise, and warns when passthrough lets any caller use the server's own tokens.
+func resolveAuthMode(cfg config.Config, logger *slog.Logger) (string, error) {
+    mode := cfg.AuthMode
+    switch mode {
+    case "auto", "":
+        mode = "passthrough"
+        if _, err := os.Stat(cfg.APIKeysFile); err == nil {
+            mode = "keys"
+        }
+        logger.Info("auth mode", "mode", mode, "keys_file", cfg.APIKeysFile)
+    case "passthrough", "keys":
+    default:
+        return "", fmt.Errorf("unknown auth mode %q (want auto, passthrough or keys)", cfg.AuthMode)
+    }
+    if mode == "passthrough" && cfg.UseEnvToken && (cfg.SiderAPIToken != "" || cfg.HasTokenPool()) {
+        logger.Warn("passthrough auth with USE_ENV_TOKEN: requests without credentials use the server's Sider tokens; create a proxy key or set AUTH_MODE=keys")
+    }
+    return mode, nil
+}
+
+// siderTokenForKey returns the Sider token a key maps to; "" selects the pool.
+func siderTokenForKey(cfg config.Config, key apikeys.Key) (string, bool) {
+    if key.SiderToken != "" {
+        return key.SiderToken, true
+    }
+    if cfg.HasTokenPool() {
+        return "", true
+    }
+    return cfg.SiderAPIToken, cfg.SiderAPIToken != ""
+}
+
+// AdminMiddleware requires the configured admin token; it never accepts Sider tokens.
+func AdminMiddleware(cfg config.Config, logger *slog.Logger) gin.HandlerFunc {
+    expected := []byte(cfg.AdminToken)
+    return func(c *gin.Context) {
+        token, err := extractBearer(c.GetHeader("Authorization"))
+        if err != nil || subtle.ConstantTimeCompare([]byte(token), expected) != 1 {
+            logger.Warn("rejected admin request", "path", c.Request.URL.Path, "remote", c.ClientIP())
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": gin.H{"type": "authentication_error", "message": "invalid admin token"}})
+            return
+        }
+        c.Next()
+    }
+}
+
 func extractBearer(header string) (string, error) {
     parts := strings.SplitN(header, " ", 2)
     if len(parts) != 2 {
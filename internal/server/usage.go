@@ -0,0 +1,91 @@
+package server
+
+import (
+    "errors"
+    "log/slog"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+
+    "sider2api/internal/config"
+    "sider2api/internal/usage"
+    "sider2api/pkg/types"
+)
+
+// defaultQuota is the quota for clients whose key does not set its own.
+func defaultQuota(cfg config.Config) usage.Quota {
+    return usage.Quota{
+        DailyTokens:     cfg.QuotaDailyTokens,
+        MonthlyTokens:   cfg.QuotaMonthlyTokens,
+        DailyRequests:   cfg.QuotaDailyRequests,
+        MonthlyRequests: cfg.QuotaMonthlyRequests,
+    }
+}
+
+// UsageMiddleware enforces quotas before the request reaches Sider and records
+// every request in the ledger once it finishes. A request holds its share of the
+// quota while it runs. It runs after AuthMiddleware.
+func UsageMiddleware(ledger *usage.Ledger, defaults usage.Quota, logger *slog.Logger) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        start := time.Now()
+        probe := probeRequest(c)
+        client, key := clientIdentity(c)
+
+        quota := usage.Quota{
+            DailyTokens:     key.DailyTokens,
+            MonthlyTokens:   key.MonthlyTokens,
+            DailyRequests:   key.DailyRequests,
+            MonthlyRequests: key.MonthlyRequests,
+        }.Merge(defaults)
+        res, err := ledger.Reserve(client, quota, probe.EstimatedTokens)
+        if err != nil {
+            var qe *usage.QuotaError
+            if errors.As(err, &qe) {
+                c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(qe.ResetAt).Seconds()))))
+            }
+            logger.Warn("quota exhausted", "client", client, "error", err)
+            if isOpenAIPath(c.Request.URL.Path) {
+                c.AbortWithStatusJSON(http.StatusTooManyRequests, types.OpenAIErrorResponse{Error: types.OpenAIError{Message: err.Error(), Type: "insufficient_quota", Code: "insufficient_quota"}})
+            } else {
+                c.AbortWithStatusJSON(http.StatusTooManyRequests, types.AnthropicError{Type: "error", Error: types.AnthropicErrorDetails{Type: "rate_limit_error", Message: err.Error()}})
+            }
+        } else {
+            c.Next()
+        }
+
+        in, out := requestTokens(c, probe)
+        model := probe.Model
+        if answered := c.GetString("upstreamModel"); answered != "" {
+            // routed aliases are billed to the model that actually answered
+            model = answered
+        }
+        status := c.Writer.Status()
+        rec := usage.Record{
+            Time:         start.UTC(),
+            Client:       client,
+            Path:         c.FullPath(),
+            Model:        model,
+            Stream:       probe.Stream,
+            InputTokens:  in,
+            OutputTokens: out,
+            LatencyMs:    time.Since(start).Milliseconds(),
+            Status:       status,
+            Outcome:      usage.Outcome(status),
+        }
+        if status >= 400 {
+            // failed requests count as a request but not as tokens
+            rec.InputTokens, rec.OutputTokens = 0, 0
+        }
+        if res != nil {
+            err = ledger.Settle(res, rec)
+        } else {
+            err = ledger.Add(rec)
+        }
+        if err != nil {
+            logger.Error("usage ledger write failed", "error", err)
+        }
+    }
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+    "log/slog"
+
+    "sider2api/internal/config"
+)
+
+// NewFromConfig builds a manager with the store backend and queue limits selected in cfg.
+func NewFromConfig(cfg config.Config, logger *slog.Logger) (*SiderSessionManager, error) {
+    store, err := OpenStore(cfg.SessionStore, cfg.SessionStorePath)
+    if err != nil {
+        return nil, err
+    }
+    m, err := NewSiderSessionManagerWithStore(store, cfg.SiderSessionMaxAge, cfg.ContinuousCID)
+    if err != nil {
+        store.Close()
+        return nil, err
+    }
+    m.SetLogger(logger)
+    m.SetQueueLimits(cfg.ConversationQueueDepth, cfg.ConversationWaitTimeout)
+    m.SetLimits(cfg.MaxSessions, cfg.MaxSessionsPerOwner)
+    return m, nil
+}
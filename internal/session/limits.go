@@ -0,0 +1,130 @@
+package session
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+)
+
+// EvictionCounters counts sessions removed by each policy since startup.
+type EvictionCounters struct {
+    LRU        int64 `json:"lru"`
+    OwnerQuota int64 `json:"owner_quota"`
+    Expired    int64 `json:"expired"`
+}
+
+// SessionLimits reports the configured caps and what they have evicted.
+type SessionLimits struct {
+    MaxSessions         int              `json:"max_sessions"`
+    MaxSessionsPerOwner int              `json:"max_sessions_per_owner"`
+    Evictions           EvictionCounters `json:"evictions"`
+}
+
+// OwnerKey derives a stable, non-reversible owner id from an auth token.
+func OwnerKey(token string) string {
+    if token == "" {
+        return ""
+    }
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:8])
+}
+
+// SetLimits caps the total number of sessions and the number per owner. Zero means unlimited.
+// Sessions over the new caps are evicted immediately.
+func (m *SiderSessionManager) SetLimits(maxSessions, maxPerOwner int) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.maxSessions = maxSessions
+    m.maxPerOwner = maxPerOwner
+    for m.maxSessions > 0 && len(m.sessions) > m.maxSessions {
+        if !m.evictOldest("", &m.evictions.LRU) {
+            break
+        }
+    }
+    for owner, n := range m.ownerCounts {
+        for m.maxPerOwner > 0 && n > m.maxPerOwner {
+            if !m.evictOldest(owner, &m.evictions.OwnerQuota) {
+                break
+            }
+            n--
+        }
+    }
+}
+
+// Limits returns the session caps and the eviction counters.
+func (m *SiderSessionManager) Limits() SessionLimits {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return SessionLimits{MaxSessions: m.maxSessions, MaxSessionsPerOwner: m.maxPerOwner, Evictions: m.evictions}
+}
+
+// makeRoom evicts sessions so one more can be added for owner; callers hold m.mu.
+func (m *SiderSessionManager) makeRoom(owner string) {
+    if owner != "" && m.maxPerOwner > 0 {
+        for m.ownerCounts[owner] >= m.maxPerOwner {
+            if !m.evictOldest(owner, &m.evictions.OwnerQuota) {
+                break
+            }
+        }
+    }
+    if m.maxSessions > 0 {
+        for len(m.sessions) >= m.maxSessions {
+            if !m.evictOldest("", &m.evictions.LRU) {
+                break
+            }
+        }
+    }
+}
+
+// evictOldest removes the least recently used idle session, limited to owner when set.
+// Sessions with a request in flight are skipped. Callers hold m.mu.
+func (m *SiderSessionManager) evictOldest(owner string, counter *int64) bool {
+    for e := m.lru.Back(); e != nil; e = e.Prev() {
+        cid := e.Value.(string)
+        s := m.sessions[cid]
+        if owner != "" && s.Owner != owner {
+            continue
+        }
+        if m.InFlight(cid) {
+            continue
+        }
+        m.remove(cid)
+        *counter++
+        return true
+    }
+    return false
+}
+
+// insert adds s as the most recently used session; callers hold m.mu.
+func (m *SiderSessionManager) insert(s *SiderSessionState) {
+    m.sessions[s.CID] = s
+    m.elems[s.CID] = m.lru.PushFront(s.CID)
+    if s.Owner != "" {
+        m.ownerCounts[s.Owner]++
+    }
+}
+
+// touch marks cid as most recently used; callers hold m.mu.
+func (m *SiderSessionManager) touch(cid string) {
+    if e, ok := m.elems[cid]; ok {
+        m.lru.MoveToFront(e)
+    }
+}
+
+// remove drops cid from memory and the store; callers hold m.mu.
+func (m *SiderSessionManager) remove(cid string) {
+    s, ok := m.sessions[cid]
+    if !ok {
+        return
+    }
+    delete(m.sessions, cid)
+    if e, ok := m.elems[cid]; ok {
+        m.lru.Remove(e)
+        delete(m.elems, cid)
+    }
+    if s.Owner != "" {
+        if m.ownerCounts[s.Owner]--; m.ownerCounts[s.Owner] <= 0 {
+            delete(m.ownerCounts, s.Owner)
+        }
+    }
+    m.unpersist(cid)
+}
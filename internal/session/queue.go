@@ -0,0 +1,103 @@
+package session
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+)
+
+// Defaults for per-conversation request serialization.
+const (
+    DefaultQueueDepth = 4
+    DefaultQueueWait  = 30 * time.Second
+)
+
+var (
+    // ErrConversationBusy is returned when a conversation already has the maximum number of queued requests.
+    ErrConversationBusy = errors.New("conversation has too many queued requests")
+    // ErrConversationWaitTimeout is returned when a request waited too long for its turn on a conversation.
+    ErrConversationWaitTimeout = errors.New("timed out waiting for in-flight request on conversation")
+)
+
+// conversationQueue serializes requests on one CID. slot holds a value while a request is in flight.
+type conversationQueue struct {
+    slot    chan struct{}
+    pending int // in-flight + waiting
+}
+
+// SetQueueLimits configures how many requests may wait behind the in-flight one and for how long.
+// A zero wait means requests wait until their context is done.
+func (m *SiderSessionManager) SetQueueLimits(depth int, wait time.Duration) {
+    m.queueMu.Lock()
+    defer m.queueMu.Unlock()
+    if depth < 0 {
+        depth = 0
+    }
+    m.queueDepth = depth
+    m.queueWait = wait
+}
+
+// Acquire waits until the caller may run a request on cid and returns a release func.
+// Requests on different CIDs never block each other; an empty CID is not serialized.
+func (m *SiderSessionManager) Acquire(ctx context.Context, cid string) (func(), error) {
+    if cid == "" {
+        return func() {}, nil
+    }
+
+    m.queueMu.Lock()
+    q, ok := m.queues[cid]
+    if !ok {
+        q = &conversationQueue{slot: make(chan struct{}, 1)}
+        m.queues[cid] = q
+    }
+    if q.pending > m.queueDepth {
+        m.queueMu.Unlock()
+        return nil, ErrConversationBusy
+    }
+    q.pending++
+    wait := m.queueWait
+    m.queueMu.Unlock()
+
+    var timeout <-chan time.Time
+    if wait > 0 {
+        t := time.NewTimer(wait)
+        defer t.Stop()
+        timeout = t.C
+    }
+
+    select {
+    case q.slot <- struct{}{}:
+    case <-timeout:
+        m.leaveQueue(cid, q)
+        return nil, ErrConversationWaitTimeout
+    case <-ctx.Done():
+        m.leaveQueue(cid, q)
+        return nil, ctx.Err()
+    }
+
+    var once sync.Once
+    return func() {
+        once.Do(func() {
+            <-q.slot
+            m.leaveQueue(cid, q)
+        })
+    }, nil
+}
+
+// InFlight reports whether a request currently holds or waits for cid.
+func (m *SiderSessionManager) InFlight(cid string) bool {
+    m.queueMu.Lock()
+    defer m.queueMu.Unlock()
+    q, ok := m.queues[cid]
+    return ok && q.pending > 0
+}
+
+func (m *SiderSessionManager) leaveQueue(cid string, q *conversationQueue) {
+    m.queueMu.Lock()
+    defer m.queueMu.Unlock()
+    q.pending--
+    if q.pending == 0 && m.queues[cid] == q {
+        delete(m.queues, cid)
+    }
+}
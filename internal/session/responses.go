@@ -0,0 +1,43 @@
+package session
+
+import (
+    "encoding/base64"
+    "errors"
+    "strings"
+)
+
+// Responses API ids carry the Sider conversation and the assistant message they
+// answer, so previous_response_id threads the next turn onto that reply. An older
+// id branches the conversation from its reply.
+const responseIDPrefix = "resp_"
+
+// ErrResponseNotFound is returned for a previous_response_id that does not name a
+// tracked conversation of the caller.
+var ErrResponseNotFound = errors.New("previous response not found")
+
+// ResponseID returns the Responses API id for the reply assistantMsgID in cid.
+func ResponseID(cid, assistantMsgID string) string {
+    return responseIDPrefix + base64.RawURLEncoding.EncodeToString([]byte(cid+"\x00"+assistantMsgID))
+}
+
+// ResolveResponse returns the conversation and parent message id for a turn that
+// continues response id. The conversation must still be tracked and belong to owner.
+func (m *SiderSessionManager) ResolveResponse(owner, id string) (cid, parentMsgID string, err error) {
+    encoded, ok := strings.CutPrefix(id, responseIDPrefix)
+    if !ok {
+        return "", "", ErrResponseNotFound
+    }
+    raw, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return "", "", ErrResponseNotFound
+    }
+    cid, parentMsgID, ok = strings.Cut(string(raw), "\x00")
+    if !ok || cid == "" || parentMsgID == "" {
+        return "", "", ErrResponseNotFound
+    }
+    s, ok := m.Snapshot(cid)
+    if !ok || s.Owner != owner {
+        return "", "", ErrResponseNotFound
+    }
+    return cid, parentMsgID, nil
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+    "encoding/base64"
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestResolveResponse(t *testing.T) {
+    m := NewSiderSessionManager(time.Hour, "")
+    m.SaveForOwner("key:alice", "cid-1", "u2", "a2", "model")
+    m.Save("cid-anon", "u1", "a1", "model")
+
+    tests := []struct {
+        name       string
+        owner      string
+        id         string
+        wantCID    string
+        wantParent string
+    }{
+        {name: "latest reply", owner: "key:alice", id: ResponseID("cid-1", "a2"), wantCID: "cid-1", wantParent: "a2"},
+        {name: "older reply branches", owner: "key:alice", id: ResponseID("cid-1", "a1"), wantCID: "cid-1", wantParent: "a1"},
+        {name: "anonymous session", owner: "", id: ResponseID("cid-anon", "a1"), wantCID: "cid-anon", wantParent: "a1"},
+        {name: "foreign owner", owner: "key:bob", id: ResponseID("cid-1", "a2")},
+        {name: "anonymous caller on an owned session", owner: "", id: ResponseID("cid-1", "a2")},
+        {name: "owner on an anonymous session", owner: "key:alice", id: ResponseID("cid-anon", "a1")},
+        {name: "untracked conversation", owner: "key:alice", id: ResponseID("cid-gone", "a1")},
+        {name: "missing prefix", owner: "key:alice", id: "cid-1"},
+        {name: "bad encoding", owner: "key:alice", id: "resp_***"},
+        {name: "no message id", owner: "key:alice", id: responseIDPrefix + base64.RawURLEncoding.EncodeToString([]byte("cid-1"))},
+        {name: "empty message id", owner: "key:alice", id: ResponseID("cid-1", "")},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            cid, parent, err := m.ResolveResponse(tt.owner, tt.id)
+            if tt.wantCID == "" {
+                if !errors.Is(err, ErrResponseNotFound) {
+                    t.Fatalf("ResolveResponse = %q, %q, %v; want ErrResponseNotFound", cid, parent, err)
+                }
+                return
+            }
+            if err != nil || cid != tt.wantCID || parent != tt.wantParent {
+                t.Fatalf("ResolveResponse = %q, %q, %v; want %q, %q", cid, parent, err, tt.wantCID, tt.wantParent)
+            }
+        })
+    }
+}
+
+func TestResponseIDRoundTrip(t *testing.T) {
+    m := NewSiderSessionManager(time.Hour, "")
+    // ids from Sider may hold characters that are not URL safe
+    cid, msg := "c/+=1 2", "m?&3"
+    m.SaveForOwner("o", cid, "u", msg, "model")
+    id := ResponseID(cid, msg)
+    if id == ResponseID(cid, "other") {
+        t.Fatal("ids collide")
+    }
+    gotCID, gotMsg, err := m.ResolveResponse("o", id)
+    if err != nil || gotCID != cid || gotMsg != msg {
+        t.Fatalf("round trip = %q, %q, %v", gotCID, gotMsg, err)
+    }
+}
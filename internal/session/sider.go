@@ -1,56 +1,198 @@
 package session
 
 import (
+    "container/list"
+    "log/slog"
+    "sort"
     "sync"
     "time"
 )
 
-// SiderSessionManager tracks upstream Sider conversation/message IDs in memory.
+// SiderSessionManager tracks upstream Sider conversation/message IDs in memory
+// and writes every change through to a Store.
 type SiderSessionManager struct {
     mu             sync.RWMutex
     sessions       map[string]*SiderSessionState
     maxAge         time.Duration
     continuousCID  string
+    store          Store
+    logger         *slog.Logger
+
+    // LRU order (front = most recent) and per-owner counts for bounded memory
+    lru         *list.List
+    elems       map[string]*list.Element
+    ownerCounts map[string]int
+    maxSessions int
+    maxPerOwner int
+    evictions   EvictionCounters
+
+    queueMu    sync.Mutex
+    queues     map[string]*conversationQueue
+    queueDepth int
+    queueWait  time.Duration
 }
 
 // SiderSessionState mirrors TS session shape.
 type SiderSessionState struct {
-    CID              string
-    UserMessageID    string
-    AssistantMessageID string
-    Model            string
-    CreatedAt        time.Time
-    LastActivity     time.Time
-    MessageCount     int
+    CID              string    `json:"cid"`
+    UserMessageID    string    `json:"user_message_id"`
+    AssistantMessageID string  `json:"assistant_message_id"`
+    Model            string    `json:"model"`
+    CreatedAt        time.Time `json:"created_at"`
+    LastActivity     time.Time `json:"last_activity"`
+    MessageCount     int       `json:"message_count"`
+    Owner            string    `json:"owner,omitempty"`
+    // CanceledAt and CanceledMessageID describe the last turn abandoned mid-generation;
+    // they are cleared by the next completed turn.
+    CanceledAt        *time.Time `json:"canceled_at,omitempty"`
+    CanceledMessageID string     `json:"canceled_message_id,omitempty"`
+    // TokenKey fingerprints the pooled Sider token whose account holds the conversation,
+    // so sticky routing survives a restart.
+    TokenKey string `json:"token_key,omitempty"`
 }
 
-// NewSiderSessionManager constructs a manager with maxAge and continuousCID hint.
+// NewSiderSessionManager constructs an in-memory manager with maxAge and continuousCID hint.
 func NewSiderSessionManager(maxAge time.Duration, continuousCID string) *SiderSessionManager {
     return &SiderSessionManager{
         sessions:      make(map[string]*SiderSessionState),
         maxAge:        maxAge,
         continuousCID: continuousCID,
+        store:         MemoryStore{},
+        logger:        slog.Default(),
+        lru:           list.New(),
+        elems:         make(map[string]*list.Element),
+        ownerCounts:   make(map[string]int),
+        queues:        make(map[string]*conversationQueue),
+        queueDepth:    DefaultQueueDepth,
+        queueWait:     DefaultQueueWait,
+    }
+}
+
+// NewSiderSessionManagerWithStore constructs a manager backed by store and restores its sessions.
+func NewSiderSessionManagerWithStore(store Store, maxAge time.Duration, continuousCID string) (*SiderSessionManager, error) {
+    m := NewSiderSessionManager(maxAge, continuousCID)
+    m.store = store
+    restored, err := store.Load()
+    if err != nil {
+        return nil, err
+    }
+    // oldest first so the most recent session ends up at the front of the LRU list
+    sort.Slice(restored, func(i, j int) bool { return restored[i].LastActivity.Before(restored[j].LastActivity) })
+    for i := range restored {
+        s := restored[i]
+        m.insert(&s)
     }
+    return m, nil
+}
+
+// SetLogger sets the logger used for store write failures.
+func (m *SiderSessionManager) SetLogger(logger *slog.Logger) {
+    if logger != nil {
+        m.logger = logger
+    }
+}
+
+// Close releases the underlying store.
+func (m *SiderSessionManager) Close() error {
+    return m.store.Close()
 }
 
 // Save stores or updates a session.
 func (m *SiderSessionManager) Save(cid, userMsgID, assistantMsgID, model string) *SiderSessionState {
+    return m.SaveForOwner("", cid, userMsgID, assistantMsgID, model)
+}
+
+// SaveForOwner stores or updates a session attributed to owner (see OwnerKey).
+// Adding a session may evict the least recently used one to stay within limits.
+func (m *SiderSessionManager) SaveForOwner(owner, cid, userMsgID, assistantMsgID, model string) *SiderSessionState {
     m.mu.Lock()
     defer m.mu.Unlock()
     now := time.Now()
     s, ok := m.sessions[cid]
     if !ok {
-        s = &SiderSessionState{CID: cid, CreatedAt: now}
-        m.sessions[cid] = s
+        m.makeRoom(owner)
+        s = &SiderSessionState{CID: cid, CreatedAt: now, Owner: owner}
+        m.insert(s)
+    } else {
+        m.touch(cid)
     }
     s.UserMessageID = userMsgID
     s.AssistantMessageID = assistantMsgID
     s.Model = model
     s.LastActivity = now
     s.MessageCount++
+    s.CanceledAt = nil
+    s.CanceledMessageID = ""
+    m.persist(s)
     return s
 }
 
+// MarkCanceled records that the turn producing assistantMsgID was abandoned before it
+// finished. The session is rewound to parentMsgID, the last completed reply, so the next
+// turn threads past the partial answer instead of onto it. A canceled first turn has no
+// reply to rewind to, so its session is dropped and the next turn starts a new one.
+func (m *SiderSessionManager) MarkCanceled(cid, parentMsgID, assistantMsgID string) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    s, ok := m.sessions[cid]
+    if !ok || s.AssistantMessageID != assistantMsgID {
+        // a later turn already moved the session on
+        return
+    }
+    if parentMsgID == "" {
+        m.remove(cid)
+        return
+    }
+    now := time.Now()
+    s.AssistantMessageID = parentMsgID
+    s.UserMessageID = ""
+    if s.MessageCount > 0 {
+        s.MessageCount--
+    }
+    s.LastActivity = now
+    s.CanceledAt = &now
+    s.CanceledMessageID = assistantMsgID
+    m.persist(s)
+}
+
+// SetTokenKey records which pooled token's account holds cid.
+func (m *SiderSessionManager) SetTokenKey(cid, tokenKey string) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    s, ok := m.sessions[cid]
+    if !ok || s.TokenKey == tokenKey {
+        return
+    }
+    s.TokenKey = tokenKey
+    m.persist(s)
+}
+
+// Import replaces a session with state rebuilt from an upstream transcript.
+func (m *SiderSessionManager) Import(owner, cid, userMsgID, assistantMsgID, model string, messageCount int) SiderSessionState {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    now := time.Now()
+    s, ok := m.sessions[cid]
+    if !ok {
+        m.makeRoom(owner)
+        s = &SiderSessionState{CID: cid, CreatedAt: now, Owner: owner}
+        m.insert(s)
+    } else {
+        m.touch(cid)
+    }
+    s.UserMessageID = userMsgID
+    s.AssistantMessageID = assistantMsgID
+    if model != "" {
+        s.Model = model
+    }
+    s.LastActivity = now
+    s.MessageCount = messageCount
+    s.CanceledAt = nil
+    s.CanceledMessageID = ""
+    m.persist(s)
+    return *s
+}
+
 // Get returns a session by CID.
 func (m *SiderSessionManager) Get(cid string) (*SiderSessionState, bool) {
     m.mu.RLock()
@@ -59,6 +201,28 @@ func (m *SiderSessionManager) Get(cid string) (*SiderSessionState, bool) {
     return s, ok
 }
 
+// Snapshot returns a copy of a session that is safe to read without holding the manager lock.
+func (m *SiderSessionManager) Snapshot(cid string) (SiderSessionState, bool) {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    s, ok := m.sessions[cid]
+    if !ok {
+        return SiderSessionState{}, false
+    }
+    return *s, true
+}
+
+// Delete removes a session by CID and reports whether it existed.
+func (m *SiderSessionManager) Delete(cid string) bool {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if _, ok := m.sessions[cid]; !ok {
+        return false
+    }
+    m.remove(cid)
+    return true
+}
+
 // NextParentMessageID returns assistant message id for given CID.
 func (m *SiderSessionManager) NextParentMessageID(cid string) string {
     m.mu.RLock()
@@ -80,10 +244,14 @@ func (m *SiderSessionManager) GetOrCreateContinuous(model string) *SiderSessionS
     defer m.mu.Unlock()
     s, ok := m.sessions[m.continuousCID]
     if !ok {
+        m.makeRoom("")
         s = &SiderSessionState{CID: m.continuousCID, Model: model, CreatedAt: time.Now()}
-        m.sessions[m.continuousCID] = s
+        m.insert(s)
+    } else {
+        m.touch(m.continuousCID)
     }
     s.LastActivity = time.Now()
+    m.persist(s)
     return s
 }
 
@@ -98,10 +266,11 @@ func (m *SiderSessionManager) Cleanup() int {
     removed := 0
     for cid, s := range m.sessions {
         if now.Sub(s.LastActivity) > m.maxAge {
-            delete(m.sessions, cid)
+            m.remove(cid)
             removed++
         }
     }
+    m.evictions.Expired += int64(removed)
     return removed
 }
 
@@ -115,3 +284,17 @@ func (m *SiderSessionManager) Stats() []SiderSessionState {
     }
     return out
 }
+
+// persist writes s through to the store; callers hold m.mu.
+func (m *SiderSessionManager) persist(s *SiderSessionState) {
+    if err := m.store.Put(*s); err != nil {
+        m.logger.Error("session store write failed", "cid", s.CID, "error", err)
+    }
+}
+
+// unpersist removes cid from the store; callers hold m.mu.
+func (m *SiderSessionManager) unpersist(cid string) {
+    if err := m.store.Delete(cid); err != nil {
+        m.logger.Error("session store delete failed", "cid", cid, "error", err)
+    }
+}
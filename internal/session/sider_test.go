@@ -0,0 +1,45 @@
+package session
+
+import (
+    "testing"
+    "time"
+)
+
+func TestMarkCanceled(t *testing.T) {
+    m := NewSiderSessionManager(time.Hour, "")
+
+    // a canceled first turn leaves nothing to thread onto
+    m.Save("c1", "u1", "a1", "model")
+    m.MarkCanceled("c1", "", "a1")
+    if _, ok := m.Snapshot("c1"); ok {
+        t.Fatal("session kept after its first turn was canceled")
+    }
+    if got := m.NextParentMessageID("c1"); got != "" {
+        t.Fatalf("parent after canceled first turn = %q, want none", got)
+    }
+    s := m.Save("c2", "u2", "a2", "model")
+    if s.MessageCount != 1 || s.CanceledAt != nil {
+        t.Fatalf("second turn = %+v, want a fresh session", *s)
+    }
+
+    // a later canceled turn rewinds to the last completed reply
+    m.Save("c2", "u3", "a3", "model")
+    m.MarkCanceled("c2", "a2", "a3")
+    s2, ok := m.Snapshot("c2")
+    if !ok {
+        t.Fatal("session dropped after a later turn was canceled")
+    }
+    if s2.AssistantMessageID != "a2" || s2.MessageCount != 1 || s2.CanceledMessageID != "a3" {
+        t.Fatalf("rewound session = %+v", s2)
+    }
+    s = m.Save("c2", "u4", "a4", "model")
+    if s.MessageCount != 2 || s.CanceledAt != nil || s.CanceledMessageID != "" {
+        t.Fatalf("turn after cancel = %+v", *s)
+    }
+
+    // a stale cancel for a turn the session has moved past is ignored
+    m.MarkCanceled("c2", "", "a3")
+    if got := m.NextParentMessageID("c2"); got != "a4" {
+        t.Fatalf("parent after stale cancel = %q, want a4", got)
+    }
+}
@@ -0,0 +1,204 @@
+package session
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+)
+
+// Store persists session state behind SiderSessionManager. The manager keeps its
+// own in-memory index and writes every change through to the store.
+type Store interface {
+    Load() ([]SiderSessionState, error)
+    Put(s SiderSessionState) error
+    Delete(cid string) error
+    Close() error
+}
+
+// OpenStore returns the backend named by kind ("memory" or "file").
+func OpenStore(kind, path string) (Store, error) {
+    switch kind {
+    case "", "memory":
+        return MemoryStore{}, nil
+    case "file":
+        return OpenFileStore(path)
+    default:
+        return nil, fmt.Errorf("unknown session store %q (want memory or file)", kind)
+    }
+}
+
+// MemoryStore keeps nothing beyond the manager's own map; state is lost on restart.
+type MemoryStore struct{}
+
+func (MemoryStore) Load() ([]SiderSessionState, error) { return nil, nil }
+func (MemoryStore) Put(SiderSessionState) error        { return nil }
+func (MemoryStore) Delete(string) error                { return nil }
+func (MemoryStore) Close() error                       { return nil }
+
+// storeRecord is one line of the append-only session log.
+type storeRecord struct {
+    Op      string             `json:"op"`
+    CID     string             `json:"cid,omitempty"`
+    Session *SiderSessionState `json:"session,omitempty"`
+}
+
+// FileStore is an append-only JSON-lines log. It is compacted on open and
+// whenever dead records outnumber live sessions.
+type FileStore struct {
+    mu      sync.Mutex
+    path    string
+    f       *os.File
+    live    map[string]SiderSessionState
+    records int
+}
+
+// OpenFileStore opens (or creates) the log at path and compacts it.
+func OpenFileStore(path string) (*FileStore, error) {
+    if path == "" {
+        return nil, fmt.Errorf("session store path is empty")
+    }
+    if dir := filepath.Dir(path); dir != "." {
+        if err := os.MkdirAll(dir, 0o755); err != nil {
+            return nil, fmt.Errorf("create session store dir: %w", err)
+        }
+    }
+    s := &FileStore{path: path, live: map[string]SiderSessionState{}}
+    if err := s.replay(); err != nil {
+        return nil, err
+    }
+    if err := s.compact(); err != nil {
+        return nil, err
+    }
+    return s, nil
+}
+
+func (s *FileStore) replay() error {
+    f, err := os.Open(s.path)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil
+        }
+        return fmt.Errorf("open session store: %w", err)
+    }
+    defer f.Close()
+
+    scanner := bufio.NewScanner(f)
+    scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+    for scanner.Scan() {
+        var rec storeRecord
+        if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+            // a torn final write after a crash; keep what we have
+            continue
+        }
+        switch rec.Op {
+        case "put":
+            if rec.Session != nil && rec.Session.CID != "" {
+                s.live[rec.Session.CID] = *rec.Session
+            }
+        case "delete":
+            delete(s.live, rec.CID)
+        }
+    }
+    return scanner.Err()
+}
+
+// compact rewrites the log with only live sessions and reopens it for appending.
+func (s *FileStore) compact() error {
+    tmp := s.path + ".tmp"
+    f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+    if err != nil {
+        return fmt.Errorf("compact session store: %w", err)
+    }
+    w := bufio.NewWriter(f)
+    enc := json.NewEncoder(w)
+    for _, st := range s.live {
+        st := st
+        if err := enc.Encode(storeRecord{Op: "put", Session: &st}); err != nil {
+            f.Close()
+            return fmt.Errorf("compact session store: %w", err)
+        }
+    }
+    if err := w.Flush(); err != nil {
+        f.Close()
+        return fmt.Errorf("compact session store: %w", err)
+    }
+    if err := f.Close(); err != nil {
+        return fmt.Errorf("compact session store: %w", err)
+    }
+    if s.f != nil {
+        s.f.Close()
+        s.f = nil
+    }
+    if err := os.Rename(tmp, s.path); err != nil {
+        return fmt.Errorf("compact session store: %w", err)
+    }
+    s.f, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+    if err != nil {
+        return fmt.Errorf("reopen session store: %w", err)
+    }
+    s.records = len(s.live)
+    return nil
+}
+
+// Load returns all sessions recovered from disk.
+func (s *FileStore) Load() ([]SiderSessionState, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    out := make([]SiderSessionState, 0, len(s.live))
+    for _, st := range s.live {
+        out = append(out, st)
+    }
+    return out, nil
+}
+
+// Put appends the latest state of a session.
+func (s *FileStore) Put(st SiderSessionState) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.live[st.CID] = st
+    return s.append(storeRecord{Op: "put", Session: &st})
+}
+
+// Delete appends a tombstone for cid.
+func (s *FileStore) Delete(cid string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.live[cid]; !ok {
+        return nil
+    }
+    delete(s.live, cid)
+    return s.append(storeRecord{Op: "delete", CID: cid})
+}
+
+// Close flushes and closes the log file.
+func (s *FileStore) Close() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.f == nil {
+        return nil
+    }
+    err := s.f.Close()
+    s.f = nil
+    return err
+}
+
+func (s *FileStore) append(rec storeRecord) error {
+    if s.f == nil {
+        return fmt.Errorf("session store closed")
+    }
+    line, err := json.Marshal(rec)
+    if err != nil {
+        return err
+    }
+    if _, err := s.f.Write(append(line, '\n')); err != nil {
+        return fmt.Errorf("write session store: %w", err)
+    }
+    s.records++
+    if s.records > 1024 && s.records > 4*len(s.live) {
+        return s.compact()
+    }
+    return nil
+}
@@ -0,0 +1,215 @@
+package siderclient
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the circuit breaker state.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig sets when the breaker trips and how long it stays open.
+type BreakerConfig struct {
+	// Window is how far back outcomes are counted.
+	Window time.Duration
+	// MinRequests is the number of outcomes in the window before rates are judged.
+	MinRequests int
+	// ErrorRate trips the breaker when this fraction of calls failed.
+	ErrorRate float64
+	// SlowThreshold marks a call slow when its first event took longer; SlowRate
+	// trips the breaker when this fraction of calls was slow. Zero disables it.
+	SlowThreshold time.Duration
+	SlowRate      float64
+	// OpenFor is how long the breaker fails fast before letting a probe through.
+	OpenFor time.Duration
+}
+
+// BreakerStatus is a point-in-time view of the breaker for health reporting.
+type BreakerStatus struct {
+	State     BreakerState
+	Requests  int
+	ErrorRate float64
+	SlowRate  float64
+	OpenedAt  time.Time
+	RetryAt   time.Time
+	LastError string
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+	slow   bool
+}
+
+// Breaker is a closed/open/half-open circuit breaker over upstream calls.
+type Breaker struct {
+	mu        sync.Mutex
+	cfg       BreakerConfig
+	state     BreakerState
+	outcomes  []outcome
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+// NewBreaker returns a closed breaker.
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	return &Breaker{cfg: cfg}
+}
+
+// Allow reports whether a call may proceed. While open it returns an error of
+// kind KindCircuitOpen; after OpenFor a single probe is let through half-open.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.openedAt.Add(b.cfg.OpenFor)) {
+			return b.openError(now)
+		}
+		b.state = BreakerHalfOpen
+		b.probing = false
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return b.openError(now)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *Breaker) openError(now time.Time) error {
+	retryAt := b.openedAt.Add(b.cfg.OpenFor)
+	msg := "circuit open after repeated upstream failures; retry in " + retryAt.Sub(now).Round(time.Second).String()
+	if b.lastError != "" {
+		msg += " (last error: " + b.lastError + ")"
+	}
+	return &UpstreamError{Kind: KindCircuitOpen, Message: msg}
+}
+
+// Record reports the outcome of an allowed call. Client cancellations and errors
+// that say nothing about Sider's health (auth, bad request, ...) count as successes.
+func (b *Breaker) Record(err error, latency time.Duration) {
+	failed := false
+	switch KindOf(err) {
+	case KindUnavailable, KindTimeout, KindStream, KindIncomplete:
+		failed = true
+	case KindCanceled:
+		b.Release()
+		return
+	}
+	slow := b.cfg.SlowThreshold > 0 && latency > b.cfg.SlowThreshold
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if failed {
+		b.lastError = err.Error()
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		// a call admitted before the breaker tripped
+		return
+	case BreakerHalfOpen:
+		b.probing = false
+		if failed || slow {
+			b.trip(now)
+		} else {
+			b.state = BreakerClosed
+			b.outcomes = nil
+		}
+		return
+	}
+
+	b.outcomes = append(b.outcomes, outcome{at: now, failed: failed, slow: slow})
+	b.prune(now)
+	requests, errRate, slowRate := b.rates()
+	if requests < b.cfg.MinRequests {
+		return
+	}
+	if (b.cfg.ErrorRate > 0 && errRate >= b.cfg.ErrorRate) || (b.cfg.SlowThreshold > 0 && b.cfg.SlowRate > 0 && slowRate >= b.cfg.SlowRate) {
+		b.trip(now)
+	}
+}
+
+// Release gives up an allowed call that never reached Sider, so a half-open
+// breaker lets the next caller probe instead.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *Breaker) trip(now time.Time) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.outcomes = nil
+}
+
+func (b *Breaker) prune(now time.Time) {
+	cutoff := now.Add(-b.cfg.Window)
+	i := 0
+	for i < len(b.outcomes) && b.outcomes[i].at.Before(cutoff) {
+		i++
+	}
+	b.outcomes = b.outcomes[i:]
+}
+
+func (b *Breaker) rates() (int, float64, float64) {
+	n := len(b.outcomes)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	var failed, slow int
+	for _, o := range b.outcomes {
+		if o.failed {
+			failed++
+		}
+		if o.slow {
+			slow++
+		}
+	}
+	return n, float64(failed) / float64(n), float64(slow) / float64(n)
+}
+
+// Status returns the current state and window statistics.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.prune(now)
+	st := BreakerStatus{State: b.state, LastError: b.lastError}
+	st.Requests, st.ErrorRate, st.SlowRate = b.rates()
+	if b.state != BreakerClosed {
+		st.OpenedAt = b.openedAt
+		st.RetryAt = b.openedAt.Add(b.cfg.OpenFor)
+		// the move to half-open happens lazily in Allow; report it as soon as a probe would pass
+		if b.state == BreakerOpen && !now.Before(st.RetryAt) {
+			st.State = BreakerHalfOpen
+		}
+		if st.State == BreakerHalfOpen {
+			st.RetryAt = time.Time{}
+		}
+	}
+	return st
+}
@@ -0,0 +1,178 @@
+package siderclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sider2api/internal/tokenpool"
+	"sider2api/pkg/types"
+)
+
+func testBreaker() *Breaker {
+	return NewBreaker(BreakerConfig{
+		Window:        time.Minute,
+		MinRequests:   4,
+		ErrorRate:     0.5,
+		SlowThreshold: 100 * time.Millisecond,
+		SlowRate:      0.75,
+		OpenFor:       20 * time.Millisecond,
+	})
+}
+
+// record admits and records n calls with the same outcome.
+func record(t *testing.T, b *Breaker, n int, err error, latency time.Duration) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if aerr := b.Allow(); aerr != nil {
+			t.Fatalf("call %d refused: %v", i, aerr)
+		}
+		b.Record(err, latency)
+	}
+}
+
+// tripped opens b and waits until it would let a probe through.
+func tripped(t *testing.T, b *Breaker) {
+	t.Helper()
+	record(t, b, 4, ErrUnavailable, 0)
+	if st := b.Status().State; st != BreakerOpen {
+		t.Fatalf("state = %v, want open", st)
+	}
+	time.Sleep(30 * time.Millisecond)
+}
+
+func TestBreakerTripsOnErrorRate(t *testing.T) {
+	b := testBreaker()
+	record(t, b, 2, nil, 0)
+	record(t, b, 1, ErrTimeout, 0)
+	if st := b.Status().State; st != BreakerClosed {
+		t.Fatalf("state = %v before MinRequests, want closed", st)
+	}
+	record(t, b, 1, ErrStream, 0)
+
+	st := b.Status()
+	if st.State != BreakerOpen {
+		t.Fatalf("state = %v at 50%% errors, want open", st.State)
+	}
+	if st.RetryAt.IsZero() || st.LastError == "" {
+		t.Errorf("status = %+v, want RetryAt and LastError", st)
+	}
+	if err := b.Allow(); KindOf(err) != KindCircuitOpen {
+		t.Fatalf("Allow = %v, want circuit open", err)
+	}
+}
+
+func TestBreakerIgnoresClientErrors(t *testing.T) {
+	b := testBreaker()
+	record(t, b, 4, ErrAuth, 0)
+	record(t, b, 4, &UpstreamError{Kind: KindBadRequest}, 0)
+	if st := b.Status().State; st != BreakerClosed {
+		t.Fatalf("state = %v, want closed", st)
+	}
+}
+
+func TestBreakerTripsOnSlowRate(t *testing.T) {
+	b := testBreaker()
+	record(t, b, 1, nil, 0)
+	record(t, b, 2, nil, time.Second)
+	if st := b.Status().State; st != BreakerClosed {
+		t.Fatalf("state = %v before MinRequests, want closed", st)
+	}
+	record(t, b, 1, nil, time.Second)
+	if st := b.Status().State; st != BreakerOpen {
+		t.Fatalf("state = %v at 75%% slow calls, want open", st)
+	}
+}
+
+func TestBreakerHalfOpenAllowsSingleProbe(t *testing.T) {
+	b := testBreaker()
+	tripped(t, b)
+
+	if st := b.Status().State; st != BreakerHalfOpen {
+		t.Fatalf("state = %v after OpenFor, want half-open", st)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := b.Allow(); KindOf(err) != KindCircuitOpen {
+		t.Fatalf("second call during probe = %v, want circuit open", err)
+	}
+
+	b.Record(nil, 0)
+	if st := b.Status().State; st != BreakerClosed {
+		t.Fatalf("state = %v after a good probe, want closed", st)
+	}
+	record(t, b, 3, nil, 0)
+}
+
+func TestBreakerFailedProbeReopens(t *testing.T) {
+	b := testBreaker()
+	tripped(t, b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	b.Record(ErrUnavailable, 0)
+	if st := b.Status().State; st != BreakerOpen {
+		t.Fatalf("state = %v after a failed probe, want open", st)
+	}
+	if err := b.Allow(); KindOf(err) != KindCircuitOpen {
+		t.Fatalf("Allow = %v, want circuit open", err)
+	}
+}
+
+func TestBreakerCanceledProbeReleasesSlot(t *testing.T) {
+	b := testBreaker()
+	tripped(t, b)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	b.Record(&UpstreamError{Kind: KindCanceled}, 0)
+	if st := b.Status().State; st != BreakerHalfOpen {
+		t.Fatalf("state = %v after a canceled probe, want half-open", st)
+	}
+	// the canceled probe said nothing about Sider, so the next caller probes instead
+	if err := b.Allow(); err != nil {
+		t.Fatalf("next probe refused: %v", err)
+	}
+	if err := b.Allow(); KindOf(err) != KindCircuitOpen {
+		t.Fatalf("second call during probe = %v, want circuit open", err)
+	}
+}
+
+func TestParseErrorKinds(t *testing.T) {
+	for k := KindUnknown + 1; k < kindCount; k++ {
+		got, err := ParseErrorKinds(k.String())
+		if err != nil || len(got) != 1 || got[0] != k {
+			t.Errorf("ParseErrorKinds(%q) = %v, %v", k.String(), got, err)
+		}
+	}
+	if _, err := ParseErrorKinds("timeout,nope"); err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
+
+func TestBreakerProbeReleasedWhenPoolExhausted(t *testing.T) {
+	b := testBreaker()
+	tripped(t, b)
+
+	pool, err := tokenpool.New([]tokenpool.Entry{{Token: "pool-token"}}, tokenpool.RoundRobin, time.Minute, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, _ := pool.Pick("", nil)
+	pool.Done(entry.Token, "quota exhausted", true)
+
+	c := New("http://127.0.0.1:0", "", time.Second, time.Second, nil)
+	c.Pool = pool
+	c.Breaker = b
+	_, err = c.ChatStream(context.Background(), types.SiderRequest{Model: "claude-haiku-4.5"}, "", nil)
+	if KindOf(err) != KindUnavailable {
+		t.Fatalf("err = %v, want unavailable", err)
+	}
+	// the refused call never reached Sider, so the probe slot is free again
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused after pool exhaustion: %v", err)
+	}
+}
@@ -1,17 +1,20 @@
 package siderclient
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"sider2api/internal/config"
 	"sider2api/internal/session"
+	"sider2api/internal/tokenpool"
 	"sider2api/pkg/types"
 )
 
@@ -19,10 +22,26 @@ import (
 type Client struct {
 	BaseURL             string
 	ConversationURL     string
+	ModelsURL           string
 	ChatTimeout         time.Duration
 	ConversationTimeout time.Duration
 	HTTPClient          *http.Client
 	Sessions            *session.SiderSessionManager
+	Retry               RetryPolicy
+	Logger              *slog.Logger
+	// Pool, when set, supplies the Sider token for calls made with an empty token.
+	Pool *tokenpool.Pool
+	// Breaker, when set, fails calls fast while Sider is unhealthy.
+	Breaker *Breaker
+	// Offline, when set, answers calls made with its test tokens without Sider.
+	Offline *Offline
+	// SSEBufferSize is the initial stream read buffer; SSEMaxEventSize caps one event.
+	// Zero uses DefaultSSEBufferSize and DefaultSSEMaxEventSize.
+	SSEBufferSize   int
+	SSEMaxEventSize int
+	// Headers is the default upstream identity; Profiles are the named alternatives.
+	Headers  config.HeaderProfile
+	Profiles map[string]config.HeaderProfile
 }
 
 // New creates a new client with defaults.
@@ -34,13 +53,97 @@ func New(baseURL, convURL string, chatTimeout, convTimeout time.Duration, sm *se
 		ConversationTimeout: convTimeout,
 		HTTPClient:          &http.Client{},
 		Sessions:            sm,
+		Retry:               DefaultRetryPolicy(),
+		Logger:              slog.Default(),
+		Headers:             config.DefaultHeaderProfile(),
 	}
 }
 
+// NewFromConfig creates a client with the endpoints, timeouts and retry policy from cfg.
+func NewFromConfig(cfg config.Config, sm *session.SiderSessionManager, logger *slog.Logger) (*Client, error) {
+	c := New(cfg.BaseURL, cfg.ConversationURL, cfg.ChatTimeout, cfg.ConversationTimeout, sm)
+	c.ModelsURL = cfg.ModelsURL
+	if logger != nil {
+		c.Logger = logger
+	}
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.HTTPClient = &http.Client{Transport: transport}
+	if cfg.UpstreamProxy != "" {
+		c.Logger.Info("using upstream proxy", "proxy", redactURL(cfg.UpstreamProxy))
+	}
+	if cfg.VCRMode != "" && cfg.VCRMode != VCROff {
+		vcr, err := NewVCR(cfg.VCRMode, cfg.VCRDir, transport, c.Logger)
+		if err != nil {
+			return nil, err
+		}
+		c.HTTPClient.Transport = vcr
+		c.Logger.Warn("upstream vcr enabled", "mode", cfg.VCRMode, "dir", cfg.VCRDir)
+	}
+	offline, err := NewOffline(cfg, c.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("offline backend: %w", err)
+	}
+	c.Offline = offline
+	if offline != nil {
+		c.Logger.Info("offline backend enabled", "tokens", len(offline.Tokens))
+	}
+	c.SSEBufferSize = cfg.SSEBufferSize
+	c.SSEMaxEventSize = cfg.SSEMaxEventSize
+	c.Retry.MaxAttempts = cfg.RetryMaxAttempts
+	c.Retry.BaseDelay = cfg.RetryBaseDelay
+	c.Retry.MaxDelay = cfg.RetryMaxDelay
+	if cfg.RetryOn != "" {
+		kinds, err := ParseErrorKinds(cfg.RetryOn)
+		if err != nil {
+			return nil, fmt.Errorf("retry-on: %w", err)
+		}
+		c.Retry.RetryOn = kinds
+	}
+	if cfg.BreakerEnabled {
+		c.Breaker = NewBreaker(BreakerConfig{
+			Window:        cfg.BreakerWindow,
+			MinRequests:   cfg.BreakerMinRequests,
+			ErrorRate:     cfg.BreakerErrorRate,
+			SlowThreshold: cfg.BreakerSlowThreshold,
+			SlowRate:      cfg.BreakerSlowRate,
+			OpenFor:       cfg.BreakerOpenFor,
+		})
+	}
+	if err := c.configureHeaders(cfg); err != nil {
+		return nil, err
+	}
+	pool, err := tokenpool.FromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("token pool: %w", err)
+	}
+	if pool != nil {
+		for _, e := range pool.Entries() {
+			if _, ok := c.Profiles[e.Profile]; e.Profile != "" && !ok {
+				return nil, fmt.Errorf("token pool: %s uses unknown header profile %q", e.Name, e.Profile)
+			}
+		}
+	}
+	if pool != nil && sm != nil {
+		// sticky bindings outlive a restart through the session store
+		pool.SetBindingLookup(func(cid string) string {
+			s, _ := sm.Snapshot(cid)
+			return s.TokenKey
+		})
+	}
+	c.Pool = pool
+	return c, nil
+}
+
 // StreamCallback is called for each SSE event during streaming
 type StreamCallback func(event types.SiderSSEResponse, partial types.SiderParsedResponse)
 
 // ChatStream posts a chat request and streams SSE responses via callback.
+// Failures are returned as *UpstreamError; an error event inside the stream ends
+// parsing and is returned alongside the partial result. Transient failures are
+// retried per c.Retry, but only while no event has been passed to callback.
 func (c *Client) ChatStream(ctx context.Context, req types.SiderRequest, authToken string, callback StreamCallback) (types.SiderParsedResponse, error) {
 	var result types.SiderParsedResponse
 
@@ -52,125 +155,297 @@ func (c *Client) ChatStream(ctx context.Context, req types.SiderRequest, authTok
 	ctx, cancel := context.WithTimeout(ctx, c.ChatTimeout)
 	defer cancel()
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, strings.NewReader(string(payload)))
-	if err != nil {
-		return result, fmt.Errorf("build request: %w", err)
+	delivered := false
+	var firstEvent time.Time
+	forward := func(evt types.SiderSSEResponse, partial types.SiderParsedResponse) {
+		delivered = true
+		if firstEvent.IsZero() {
+			firstEvent = time.Now()
+		}
+		if callback != nil {
+			callback(evt, partial)
+		}
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+authToken)
-	httpReq.Header.Set("Origin", "chrome-extension://dhoenijjpgpeimemopealfcbiecgceod")
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0")
-	httpReq.Header.Set("X-Time-Zone", "Asia/Shanghai")
-	httpReq.Header.Set("X-App-Version", "5.13.0")
-	httpReq.Header.Set("X-App-Name", "ChitChat_Edge_Ext")
+	maxAttempts := c.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	pooled := authToken == "" && c.Pool != nil
+	// offline calls never reach Sider, so they must not sway the breaker
+	breaker := c.Breaker
+	if c.Offline.Handles(authToken) {
+		breaker = nil
+	}
+	tried := map[string]bool{}
+	retries := 0
+	for attempt := 1; ; attempt++ {
+		if breaker != nil {
+			if berr := breaker.Allow(); berr != nil {
+				berr.(*UpstreamError).Attempts = attempt - 1
+				c.Logger.Warn("sider circuit open, failing fast", "attempt", attempt)
+				return result, berr
+			}
+		}
 
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return result, fmt.Errorf("sider request: %w", err)
+		token := authToken
+		attemptCtx := ctx
+		if pooled {
+			entry, perr := c.Pool.Pick(req.CID, tried)
+			if perr != nil {
+				// no call was made, so a half-open probe slot must not stay taken
+				if breaker != nil {
+					breaker.Release()
+				}
+				if err == nil {
+					err = &UpstreamError{Kind: KindUnavailable, Message: perr.Error(), Err: perr}
+				}
+				c.Logger.Warn("sider token pool exhausted", "attempt", attempt, "error", perr)
+				return result, err
+			}
+			token = entry.Token
+			if entry.Profile != "" {
+				attemptCtx = WithHeaderProfile(ctx, entry.Profile)
+			}
+		}
+		start := time.Now()
+		firstEvent = time.Time{}
+
+		c.Logger.Debug("sider chat attempt", "attempt", attempt, "max_attempts", maxAttempts, "model", req.Model, "cid", req.CID)
+		result, err = c.chatOnce(attemptCtx, payload, token, forward)
+		result.Attempts = attempt
+		if breaker != nil {
+			latency := time.Since(start)
+			if !firstEvent.IsZero() {
+				latency = firstEvent.Sub(start)
+			}
+			breaker.Record(err, latency)
+		}
+		if pooled {
+			c.reportToken(token, err)
+		}
+		if KindOf(err) == KindCanceled {
+			c.recordCanceled(req, result)
+			return result, err
+		}
+		if err == nil {
+			if pooled {
+				c.Pool.Bind(result.ConversationID, token)
+				if c.Sessions != nil && result.ConversationID != "" {
+					c.Sessions.SetTokenKey(result.ConversationID, tokenpool.Fingerprint(token))
+				}
+			}
+			if attempt > 1 {
+				c.Logger.Info("sider chat succeeded after retry", "attempt", attempt)
+			}
+			return result, nil
+		}
+
+		var ue *UpstreamError
+		if errors.As(err, &ue) {
+			ue.Attempts = attempt
+		}
+		// a rejected pool token is swapped for the next one straight away; this
+		// does not count against the retry budget
+		if pooled && !delivered && isTokenFailure(err) {
+			tried[token] = true
+			c.Logger.Warn("sider token rejected, failing over", "attempt", attempt, "kind", KindOf(err).String(), "token", tokenpool.Mask(token))
+			continue
+		}
+		retries++
+		if delivered || retries >= maxAttempts || !c.Retry.shouldRetry(ctx, err) {
+			if attempt > 1 {
+				c.Logger.Warn("sider chat failed", "attempt", attempt, "kind", KindOf(err).String(), "error", err)
+			}
+			return result, err
+		}
+
+		delay := c.Retry.backoff(retries)
+		c.Logger.Warn("sider chat attempt failed, retrying", "attempt", attempt, "max_attempts", maxAttempts, "kind", KindOf(err).String(), "delay", delay, "error", err)
+		if serr := sleep(ctx, delay); serr != nil {
+			return result, err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return result, fmt.Errorf("sider api error: %s %s", resp.Status, string(body))
+// recordCanceled logs what Sider had produced before the caller went away and rewinds
+// the session so the next turn threads onto the last completed reply.
+func (c *Client) recordCanceled(req types.SiderRequest, partial types.SiderParsedResponse) {
+	text := strings.Join(partial.TextParts, "")
+	c.Logger.Info("sider chat canceled", "cid", partial.ConversationID, "model", req.Model, "text_bytes", len(text), "reasoning_parts", len(partial.ReasoningParts), "tool_results", len(partial.ToolResults))
+	c.Logger.Debug("sider chat canceled with partial output", "cid", partial.ConversationID, "partial", truncateForLog(text))
+	if c.Sessions != nil && partial.ConversationID != "" && partial.MessageIDs != nil {
+		c.Sessions.MarkCanceled(partial.ConversationID, req.ParentMessageID, partial.MessageIDs.Assistant)
 	}
+}
 
-	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "text/event-stream") {
-		return result, errors.New("expected SSE response from Sider API")
+// isTokenFailure reports whether err means the token itself is unusable for now.
+func isTokenFailure(err error) bool {
+	switch KindOf(err) {
+	case KindAuth, KindQuotaExhausted:
+		return true
 	}
+	return false
+}
 
-	return c.parseSSEStream(resp.Body, callback)
+// reportToken records the outcome of a pooled call, cooling the token down on
+// auth and quota errors.
+func (c *Client) reportToken(token string, err error) {
+	if err == nil {
+		c.Pool.Done(token, "", false)
+		return
+	}
+	if errors.Is(err, context.Canceled) || KindOf(err) == KindCanceled {
+		c.Pool.Done(token, "", false)
+		return
+	}
+	c.Pool.Done(token, err.Error(), isTokenFailure(err))
 }
 
-// Chat posts a chat request and parses SSE response.
-func (c *Client) Chat(ctx context.Context, req types.SiderRequest, authToken string) (types.SiderParsedResponse, error) {
-	var result types.SiderParsedResponse
+// WithSessionOwner attributes the sessions saved by calls made with ctx to owner, the
+// caller, instead of to the Sider token the call used; pooled calls have no token of
+// the caller's own.
+func WithSessionOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, sessionOwnerKey, owner)
+}
 
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return result, fmt.Errorf("marshal request: %w", err)
+// sessionOwner returns the owner set by WithSessionOwner, else the owner key of authToken.
+func sessionOwner(ctx context.Context, authToken string) string {
+	if owner, ok := ctx.Value(sessionOwnerKey).(string); ok {
+		return owner
 	}
+	return session.OwnerKey(authToken)
+}
 
-	ctx, cancel := context.WithTimeout(ctx, c.ChatTimeout)
-	defer cancel()
+// chatOnce performs a single upstream request.
+func (c *Client) chatOnce(ctx context.Context, payload []byte, authToken string, callback StreamCallback) (types.SiderParsedResponse, error) {
+	var result types.SiderParsedResponse
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, strings.NewReader(string(payload)))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL, bytes.NewReader(payload))
 	if err != nil {
 		return result, fmt.Errorf("build request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+authToken)
-	httpReq.Header.Set("Origin", "chrome-extension://dhoenijjpgpeimemopealfcbiecgceod")
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0")
-	httpReq.Header.Set("X-Time-Zone", "Asia/Shanghai")
-	httpReq.Header.Set("X-App-Version", "5.13.0")
-	httpReq.Header.Set("X-App-Name", "ChitChat_Edge_Ext")
+	c.setIdentityHeaders(ctx, httpReq)
 
-	resp, err := c.HTTPClient.Do(httpReq)
+	resp, err := c.httpClientFor(authToken).Do(httpReq)
 	if err != nil {
-		return result, fmt.Errorf("sider request: %w", err)
+		return result, transportError(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return result, fmt.Errorf("sider api error: %s %s", resp.Status, string(body))
+		return result, httpError(resp.StatusCode, body)
 	}
 
 	if ct := resp.Header.Get("Content-Type"); !strings.Contains(ct, "text/event-stream") {
-		return result, errors.New("expected SSE response from Sider API")
+		// Sider answers some failures with a 200 JSON envelope instead of a stream
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if ue := httpError(resp.StatusCode, body); ue.Kind != KindUnknown {
+			return result, ue
+		}
+		return result, &UpstreamError{Kind: KindStream, Status: resp.StatusCode, Message: "expected SSE response from Sider API"}
 	}
 
-	return c.parseSSE(resp.Body)
+	result, err = c.parseSSEStream(resp.Body, sessionOwner(ctx, authToken), callback)
+	if err != nil && ctx.Err() != nil {
+		return result, transportError(ctx.Err())
+	}
+	if err == nil && result.ConversationID == "" && len(result.TextParts) == 0 && len(result.ReasoningParts) == 0 {
+		return result, &UpstreamError{Kind: KindIncomplete, Message: "stream ended before message_start"}
+	}
+	return result, err
 }
 
-func (c *Client) parseSSEStream(body io.Reader, callback StreamCallback) (types.SiderParsedResponse, error) {
+// Chat posts a chat request and parses SSE response.
+func (c *Client) Chat(ctx context.Context, req types.SiderRequest, authToken string) (types.SiderParsedResponse, error) {
+	return c.ChatStream(ctx, req, authToken, nil)
+}
+
+func (c *Client) parseSSEStream(body io.Reader, owner string, callback StreamCallback) (types.SiderParsedResponse, error) {
 	var result types.SiderParsedResponse
 	result.ReasoningParts = []string{}
 	result.TextParts = []string{}
 	result.ToolResults = []types.SiderToolResult{}
 
-	scanner := bufio.NewScanner(body)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, ":") {
-			continue
+	reader := NewSSEReader(body, c.SSEBufferSize, c.SSEMaxEventSize)
+	debug := c.Logger.Enabled(context.Background(), slog.LevelDebug)
+	for {
+		sse, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
-		if !strings.HasPrefix(line, "data:") {
-			continue
+		if err != nil {
+			return result, &UpstreamError{Kind: KindStream, InStream: true, Message: "read sse", Err: err}
+		}
+		if debug {
+			c.Logger.Debug("sider sse event", "event", sse.Event, "id", sse.ID, "bytes", len(sse.Data), "data", truncateForLog(sse.Data))
 		}
-		dataStr := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-		if dataStr == "[DONE]" {
+		if sse.Data == "[DONE]" {
 			break
 		}
-		var evt types.SiderSSEResponse
-		if err := json.Unmarshal([]byte(dataStr), &evt); err != nil {
-			// skip malformed lines
+
+		events, ok := decodeSiderEvents(sse.Data)
+		if !ok {
+			c.Logger.Debug("skipping unparseable sider sse event", "event", sse.Event, "id", sse.ID, "bytes", len(sse.Data))
 			continue
 		}
-		c.processEvent(&result, evt)
+		for _, evt := range events {
+			if err := c.processEvent(&result, evt, owner); err != nil {
+				return result, err
+			}
 
-		// Call callback with current event and partial result
-		if callback != nil {
-			callback(evt, result)
+			// Call callback with current event and partial result
+			if callback != nil {
+				callback(evt, result)
+			}
 		}
 	}
+	return result, nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return result, fmt.Errorf("read sse: %w", err)
+// decodeSiderEvents parses one SSE data payload. The whole payload is tried first so a
+// JSON document split over several data lines works; if that fails each line is tried
+// on its own, for streams that put consecutive events on data lines without a blank
+// line between them.
+func decodeSiderEvents(data string) ([]types.SiderSSEResponse, bool) {
+	var evt types.SiderSSEResponse
+	if err := json.Unmarshal([]byte(data), &evt); err == nil {
+		return []types.SiderSSEResponse{evt}, true
 	}
-	return result, nil
+	if !strings.Contains(data, "\n") {
+		return nil, false
+	}
+	var events []types.SiderSSEResponse
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" || line == "[DONE]" {
+			continue
+		}
+		var evt types.SiderSSEResponse
+		if err := json.Unmarshal([]byte(line), &evt); err != nil {
+			// skip malformed lines
+			continue
+		}
+		events = append(events, evt)
+	}
+	return events, len(events) > 0
 }
 
-func (c *Client) parseSSE(body io.Reader) (types.SiderParsedResponse, error) {
-	return c.parseSSEStream(body, nil)
+// truncateForLog keeps debug logs readable when events carry large tool payloads.
+func truncateForLog(s string) string {
+	const limit = 4096
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + fmt.Sprintf("... (%d more bytes)", len(s)-limit)
 }
 
-func (c *Client) processEvent(result *types.SiderParsedResponse, evt types.SiderSSEResponse) {
+func (c *Client) processEvent(result *types.SiderParsedResponse, evt types.SiderSSEResponse, owner string) error {
 	if evt.Code != 0 {
-		return
+		return streamError(evt.Code, evt.Msg)
 	}
 	data := evt.Data
 	result.Model = data.Model
@@ -181,7 +456,7 @@ func (c *Client) processEvent(result *types.SiderParsedResponse, evt types.Sider
 			result.ConversationID = data.MessageStart.CID
 			result.MessageIDs = &types.SiderMessageIDs{User: data.MessageStart.UserMessageID, Assistant: data.MessageStart.AssistantMessageID}
 			if c.Sessions != nil {
-				c.Sessions.Save(data.MessageStart.CID, data.MessageStart.UserMessageID, data.MessageStart.AssistantMessageID, data.Model)
+				c.Sessions.SaveForOwner(owner, data.MessageStart.CID, data.MessageStart.UserMessageID, data.MessageStart.AssistantMessageID, data.Model)
 			}
 		}
 	case "reasoning_content":
@@ -196,7 +471,10 @@ func (c *Client) processEvent(result *types.SiderParsedResponse, evt types.Sider
 		if data.ToolCall != nil {
 			c.handleToolCall(result, data.ToolCall)
 		}
+	default:
+		c.Logger.Debug("ignoring unknown sider event type", "type", data.Type)
 	}
+	return nil
 }
 
 func (c *Client) handleToolCall(result *types.SiderParsedResponse, tc *types.SiderToolCall) {
@@ -233,29 +511,46 @@ func (c *Client) handleToolCall(result *types.SiderParsedResponse, tc *types.Sid
 	}
 }
 
-// ConversationHistory fetches a conversation transcript (optional path matching TS async flow).
+// ConversationHistoryResponse is the upstream conversation transcript envelope.
 type ConversationHistoryResponse struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
 	Data struct {
-		Conversation any `json:"conversation"`
-		Messages     []struct {
-			ID              string `json:"id"`
-			ParentMessageID string `json:"parent_message_id"`
-			Role            string `json:"role"`
-			MultiContent    []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"multi_content"`
-		} `json:"messages"`
+		Conversation any                              `json:"conversation"`
+		Messages     []types.SiderConversationMessage `json:"messages"`
 	} `json:"data"`
 }
 
-// FetchConversationHistory is provided for parity but not yet used deeply.
-func (c *Client) FetchConversationHistory(ctx context.Context, cid, authToken string, limit int) (*ConversationHistoryResponse, error) {
+// FetchConversationHistory fetches up to limit messages of an upstream conversation.
+func (c *Client) FetchConversationHistory(ctx context.Context, cid, authToken string, limit int) (_ *ConversationHistoryResponse, err error) {
 	ctx, cancel := context.WithTimeout(ctx, c.ConversationTimeout)
 	defer cancel()
 
+	if c.Breaker != nil && !c.Offline.Handles(authToken) {
+		if err := c.Breaker.Allow(); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		defer func() { c.Breaker.Record(err, time.Since(start)) }()
+	}
+
+	if authToken == "" && c.Pool != nil {
+		entry, perr := c.Pool.Pick(cid, nil)
+		if perr != nil {
+			return nil, &UpstreamError{Kind: KindUnavailable, Message: perr.Error(), Err: perr}
+		}
+		authToken = entry.Token
+		if entry.Profile != "" {
+			ctx = WithHeaderProfile(ctx, entry.Profile)
+		}
+		defer func() {
+			c.reportToken(authToken, err)
+			if err == nil {
+				c.Pool.Bind(cid, authToken)
+			}
+		}()
+	}
+
 	payload := map[string]any{"cid": cid, "limit": limit}
 	body, _ := json.Marshal(payload)
 
@@ -265,28 +560,33 @@ func (c *Client) FetchConversationHistory(ctx context.Context, cid, authToken st
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	req.Header.Set("Origin", "chrome-extension://dhoenijjpgpeimemopealfcbiecgceod")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/139.0.0.0 Safari/537.36 Edg/139.0.0.0")
-	req.Header.Set("X-Time-Zone", "Asia/Shanghai")
-	req.Header.Set("X-App-Version", "5.13.0")
-	req.Header.Set("X-App-Name", "ChitChat_Edge_Ext")
+	c.setIdentityHeaders(ctx, req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.httpClientFor(authToken).Do(req)
 	if err != nil {
-		return nil, err
+		return nil, transportError(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("conversation history http error: %s", resp.Status)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, httpError(resp.StatusCode, body)
 	}
 
 	var parsed ConversationHistoryResponse
 	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, err
+		return nil, &UpstreamError{Kind: KindUnavailable, Message: "decode conversation history", Err: err}
 	}
 	if parsed.Code != 0 {
-		return nil, fmt.Errorf("conversation history error: %s", parsed.Msg)
+		ue := httpError(resp.StatusCode, nil)
+		if k := kindFromMessage(parsed.Code, parsed.Msg); k != KindUnknown {
+			ue.Kind = k
+		} else if ue.Kind == KindUnknown {
+			ue.Kind = KindBadRequest
+		}
+		ue.Code = parsed.Code
+		ue.Message = parsed.Msg
+		return nil, ue
 	}
 	return &parsed, nil
 }
@@ -0,0 +1,243 @@
+package siderclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind classifies upstream failures so callers can map them to API errors.
+type ErrorKind int
+
+const (
+	KindUnknown ErrorKind = iota
+	KindAuth
+	KindRateLimit
+	KindQuotaExhausted
+	KindModelUnavailable
+	KindTimeout
+	KindUnavailable
+	KindBadRequest
+	KindStream
+	KindIncomplete
+	KindCanceled
+	KindCircuitOpen
+
+	// kindCount ends the enum; new kinds go above it.
+	kindCount
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindAuth:
+		return "auth"
+	case KindRateLimit:
+		return "rate_limit"
+	case KindQuotaExhausted:
+		return "quota_exhausted"
+	case KindModelUnavailable:
+		return "model_unavailable"
+	case KindTimeout:
+		return "timeout"
+	case KindUnavailable:
+		return "unavailable"
+	case KindBadRequest:
+		return "bad_request"
+	case KindStream:
+		return "stream"
+	case KindIncomplete:
+		return "incomplete"
+	case KindCanceled:
+		return "canceled"
+	case KindCircuitOpen:
+		return "circuit_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Sentinels for errors.Is; any *UpstreamError of the same kind matches.
+var (
+	ErrAuth             = &UpstreamError{Kind: KindAuth, Message: "upstream authentication failed"}
+	ErrRateLimit        = &UpstreamError{Kind: KindRateLimit, Message: "upstream rate limit exceeded"}
+	ErrQuotaExhausted   = &UpstreamError{Kind: KindQuotaExhausted, Message: "upstream quota exhausted"}
+	ErrModelUnavailable = &UpstreamError{Kind: KindModelUnavailable, Message: "model unavailable upstream"}
+	ErrTimeout          = &UpstreamError{Kind: KindTimeout, Message: "upstream request timed out"}
+	ErrUnavailable      = &UpstreamError{Kind: KindUnavailable, Message: "upstream unavailable"}
+	ErrStream           = &UpstreamError{Kind: KindStream, Message: "upstream stream error"}
+	ErrIncomplete       = &UpstreamError{Kind: KindIncomplete, Message: "upstream stream ended before message_start"}
+	ErrCircuitOpen      = &UpstreamError{Kind: KindCircuitOpen, Message: "upstream circuit breaker is open"}
+)
+
+// UpstreamError is returned for every failure talking to Sider.
+type UpstreamError struct {
+	Kind ErrorKind
+	// Status is the upstream HTTP status, 0 when the failure was not an HTTP response.
+	Status int
+	// Code is the Sider payload code, 0 when absent.
+	Code int
+	// InStream is set when the error arrived as an SSE event after the stream started.
+	InStream bool
+	// Attempts is how many requests were sent before giving up.
+	Attempts int
+	Message  string
+	Err      error
+	// Upstream names the provider that failed; empty means Sider.
+	Upstream string
+}
+
+func (e *UpstreamError) Error() string {
+	var b strings.Builder
+	if e.Upstream != "" {
+		b.WriteString(e.Upstream)
+		b.WriteString(" ")
+	} else {
+		b.WriteString("sider ")
+	}
+	b.WriteString(e.Kind.String())
+	b.WriteString(" error")
+	if e.Status != 0 {
+		fmt.Fprintf(&b, " (http %d)", e.Status)
+	}
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " (code %d)", e.Code)
+	}
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	if e.Err != nil {
+		b.WriteString(": ")
+		b.WriteString(e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *UpstreamError) Unwrap() error { return e.Err }
+
+// Is matches sentinels by kind.
+func (e *UpstreamError) Is(target error) bool {
+	t, ok := target.(*UpstreamError)
+	return ok && t.Kind == e.Kind
+}
+
+// KindOf returns the kind of err, or KindUnknown when it is not an *UpstreamError.
+func KindOf(err error) ErrorKind {
+	var ue *UpstreamError
+	if errors.As(err, &ue) {
+		return ue.Kind
+	}
+	return KindUnknown
+}
+
+// AttemptsOf returns how many upstream attempts produced err, or 0 when unknown.
+func AttemptsOf(err error) int {
+	var ue *UpstreamError
+	if errors.As(err, &ue) {
+		return ue.Attempts
+	}
+	return 0
+}
+
+// transportError classifies a failure from HTTPClient.Do or reading the body.
+func transportError(err error) *UpstreamError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &UpstreamError{Kind: KindTimeout, Err: err}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &UpstreamError{Kind: KindCanceled, Err: err}
+	}
+	return &UpstreamError{Kind: KindUnavailable, Err: err}
+}
+
+// httpError classifies a non-2xx response (or a non-SSE body) using status and payload.
+func httpError(status int, body []byte) *UpstreamError {
+	msg := strings.TrimSpace(string(body))
+	code := 0
+	var payload struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if json.Unmarshal(body, &payload) == nil && (payload.Code != 0 || payload.Msg != "") {
+		code = payload.Code
+		msg = payload.Msg
+	}
+
+	kind := kindFromMessage(code, msg)
+	if kind == KindUnknown {
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			kind = KindAuth
+		case status == http.StatusPaymentRequired:
+			kind = KindQuotaExhausted
+		case status == http.StatusTooManyRequests:
+			kind = KindRateLimit
+		case status == http.StatusNotFound:
+			kind = KindModelUnavailable
+		case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+			kind = KindTimeout
+		case status >= 500:
+			kind = KindUnavailable
+		case status >= 400:
+			kind = KindBadRequest
+		}
+	}
+	return &UpstreamError{Kind: kind, Status: status, Code: code, Message: msg}
+}
+
+// ClassifyTransport classifies a failure to reach any upstream, the way Sider
+// transport failures are classified.
+func ClassifyTransport(err error) *UpstreamError {
+	return transportError(err)
+}
+
+// ClassifyResponse classifies a failed response from any upstream by status and body.
+func ClassifyResponse(status int, body []byte) *UpstreamError {
+	return httpError(status, body)
+}
+
+// streamError classifies an SSE event whose code is non-zero.
+func streamError(code int, msg string) *UpstreamError {
+	kind := kindFromMessage(code, msg)
+	if kind == KindUnknown {
+		kind = KindStream
+	}
+	return &UpstreamError{Kind: kind, Code: code, InStream: true, Message: msg}
+}
+
+// kindFromMessage recognizes Sider payload codes and messages. Sider reuses HTTP-like
+// numbers in its code field and otherwise only explains itself in msg.
+func kindFromMessage(code int, msg string) ErrorKind {
+	switch code {
+	case 401, 403:
+		return KindAuth
+	case 402:
+		return KindQuotaExhausted
+	case 429:
+		return KindRateLimit
+	}
+	m := strings.ToLower(msg)
+	switch {
+	case containsAny(m, "quota", "credit", "insufficient", "upgrade your plan", "usage limit"):
+		return KindQuotaExhausted
+	case containsAny(m, "rate limit", "too many", "too frequent", "frequency"):
+		return KindRateLimit
+	case containsAny(m, "unauthorized", "invalid token", "token expired", "not logged in", "login"):
+		return KindAuth
+	case strings.Contains(m, "model") && containsAny(m, "not found", "unavailable", "not support", "invalid", "offline"):
+		return KindModelUnavailable
+	}
+	return KindUnknown
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package siderclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sider2api/internal/config"
+)
+
+type ctxKey int
+
+const (
+	timeZoneKey ctxKey = iota
+	profileKey
+	sessionOwnerKey
+)
+
+// WithTimeZone makes upstream calls made with ctx send tz as X-Time-Zone.
+func WithTimeZone(ctx context.Context, tz string) context.Context {
+	return context.WithValue(ctx, timeZoneKey, tz)
+}
+
+// WithHeaderProfile selects a named header profile for upstream calls made with ctx.
+func WithHeaderProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, profileKey, name)
+}
+
+// ValidTimeZone reports whether tz is an IANA time zone name.
+func ValidTimeZone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// configureHeaders sets the default identity from cfg and loads named profiles.
+func (c *Client) configureHeaders(cfg config.Config) error {
+	c.Headers = cfg.Headers.Inherit(config.DefaultHeaderProfile())
+	if cfg.HeaderProfilesFile != "" {
+		profiles, err := config.LoadHeaderProfiles(cfg.HeaderProfilesFile, c.Headers)
+		if err != nil {
+			return err
+		}
+		c.Profiles = profiles
+	}
+	if cfg.HeaderProfile != "" {
+		p, ok := c.Profiles[cfg.HeaderProfile]
+		if !ok {
+			return fmt.Errorf("header profile %q not found", cfg.HeaderProfile)
+		}
+		c.Headers = p
+	}
+	for name, p := range c.Profiles {
+		if p.TimeZone != "" && !ValidTimeZone(p.TimeZone) {
+			return fmt.Errorf("header profile %q: invalid time zone %q", name, p.TimeZone)
+		}
+	}
+	if c.Headers.TimeZone != "" && !ValidTimeZone(c.Headers.TimeZone) {
+		return fmt.Errorf("invalid time zone %q", c.Headers.TimeZone)
+	}
+	return nil
+}
+
+// headerProfile resolves the profile for ctx, falling back to the default one.
+func (c *Client) headerProfile(ctx context.Context) config.HeaderProfile {
+	name, _ := ctx.Value(profileKey).(string)
+	if name == "" {
+		return c.Headers
+	}
+	if p, ok := c.Profiles[name]; ok {
+		return p
+	}
+	c.Logger.Warn("unknown header profile, using default", "profile", name)
+	return c.Headers
+}
+
+// setIdentityHeaders applies the extension identity headers to an upstream request.
+func (c *Client) setIdentityHeaders(ctx context.Context, req *http.Request) {
+	p := c.headerProfile(ctx)
+	for k, v := range p.Extra {
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "Content-Type":
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Origin", p.Origin)
+	req.Header.Set("User-Agent", p.UserAgent)
+	req.Header.Set("X-App-Version", p.AppVersion)
+	req.Header.Set("X-App-Name", p.AppName)
+
+	tz, _ := ctx.Value(timeZoneKey).(string)
+	if tz == "" {
+		tz = p.TimeZone
+	}
+	if tz == "" {
+		tz = localTimeZone()
+	}
+	req.Header.Set("X-Time-Zone", tz)
+}
+
+var (
+	localZoneOnce sync.Once
+	localZone     string
+)
+
+// localTimeZone returns the IANA name of the server's zone, or UTC when it
+// cannot be determined.
+func localTimeZone() string {
+	localZoneOnce.Do(func() {
+		localZone = "UTC"
+		if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); ValidTimeZone(tz) {
+			localZone = tz
+			return
+		}
+		if name := time.Local.String(); ValidTimeZone(name) {
+			localZone = name
+			return
+		}
+		if target, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+			if i := strings.Index(target, "zoneinfo/"); i >= 0 && ValidTimeZone(target[i+len("zoneinfo/"):]) {
+				localZone = target[i+len("zoneinfo/"):]
+			}
+		}
+	})
+	return localZone
+}
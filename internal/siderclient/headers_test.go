@@ -0,0 +1,110 @@
+package siderclient
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sider2api/internal/config"
+)
+
+const testProfiles = `{
+  "mobile": {"user_agent": "Mobile/1.0", "time_zone": "Asia/Tokyo", "headers": {"X-Device": "phone", "Authorization": "Bearer leaked"}},
+  "beta": {"app_version": "9.9.9"}
+}`
+
+// headerClient configures a client from cfg with the test profiles file.
+func headerClient(t *testing.T, cfg config.Config) (*Client, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(path, []byte(testProfiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.HeaderProfilesFile = path
+	c := New("", "", 0, 0, nil)
+	c.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return c, c.configureHeaders(cfg)
+}
+
+func TestIdentityHeaders(t *testing.T) {
+	def := config.DefaultHeaderProfile()
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		ctx     context.Context
+		want    map[string]string
+		absent  []string
+		wantErr string
+	}{
+		{
+			name: "default profile",
+			cfg:  config.Config{Headers: config.HeaderProfile{TimeZone: "Europe/Berlin"}},
+			ctx:  context.Background(),
+			want: map[string]string{"Origin": def.Origin, "User-Agent": def.UserAgent, "X-App-Version": def.AppVersion, "X-Time-Zone": "Europe/Berlin"},
+		},
+		{
+			name:   "named profile inherits the default",
+			cfg:    config.Config{Headers: config.HeaderProfile{AppVersion: "6.0.0"}},
+			ctx:    WithHeaderProfile(context.Background(), "mobile"),
+			want:   map[string]string{"Origin": def.Origin, "User-Agent": "Mobile/1.0", "X-App-Version": "6.0.0", "X-Time-Zone": "Asia/Tokyo", "X-Device": "phone"},
+			absent: []string{"Authorization"},
+		},
+		{
+			name: "request time zone wins over the profile",
+			ctx:  WithTimeZone(WithHeaderProfile(context.Background(), "mobile"), "America/Lima"),
+			want: map[string]string{"User-Agent": "Mobile/1.0", "X-Time-Zone": "America/Lima"},
+		},
+		{
+			name: "unknown profile falls back to the default",
+			ctx:  WithHeaderProfile(context.Background(), "missing"),
+			want: map[string]string{"User-Agent": def.UserAgent},
+		},
+		{
+			name: "HEADER_PROFILE picks the default profile",
+			cfg:  config.Config{HeaderProfile: "beta"},
+			ctx:  context.Background(),
+			want: map[string]string{"User-Agent": def.UserAgent, "X-App-Version": "9.9.9"},
+		},
+		{
+			name:    "unknown HEADER_PROFILE",
+			cfg:     config.Config{HeaderProfile: "gamma"},
+			wantErr: `header profile "gamma" not found`,
+		},
+		{
+			name:    "invalid time zone",
+			cfg:     config.Config{Headers: config.HeaderProfile{TimeZone: "Mars/Base"}},
+			wantErr: "invalid time zone",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := headerClient(t, tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest(http.MethodPost, "http://sider.invalid", nil)
+			c.setIdentityHeaders(tt.ctx, req)
+			for k, v := range tt.want {
+				if got := req.Header.Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+			for _, k := range tt.absent {
+				if got := req.Header.Get(k); got != "" {
+					t.Errorf("%s = %q, want unset", k, got)
+				}
+			}
+		})
+	}
+}
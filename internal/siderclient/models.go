@@ -0,0 +1,145 @@
+package siderclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"sider2api/internal/models"
+)
+
+// FetchModels lists the models served at ModelsURL. The response may be a Sider
+// envelope ({"code":0,"data":...}) or a bare list, and the list may hold model names
+// or objects with registry fields; "model" and "name" are accepted for the id and
+// the display name.
+func (c *Client) FetchModels(ctx context.Context, authToken string) (_ []models.Model, err error) {
+	if c.ModelsURL == "" {
+		return nil, errors.New("no model list URL configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.ConversationTimeout)
+	defer cancel()
+
+	if authToken == "" && c.Pool != nil {
+		entry, perr := c.Pool.Pick("", nil)
+		if perr != nil {
+			return nil, &UpstreamError{Kind: KindUnavailable, Message: perr.Error(), Err: perr}
+		}
+		authToken = entry.Token
+		if entry.Profile != "" {
+			ctx = WithHeaderProfile(ctx, entry.Profile)
+		}
+		defer func() { c.reportToken(authToken, err) }()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.ModelsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	c.setIdentityHeaders(ctx, req)
+
+	resp, err := c.httpClientFor(authToken).Do(req)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, transportError(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, httpError(resp.StatusCode, body)
+	}
+	return parseModelList(resp.StatusCode, body)
+}
+
+// modelEntry is one object in a model list.
+type modelEntry struct {
+	models.Model
+	SiderModel string `json:"model"`
+	Name       string `json:"name"`
+}
+
+func parseModelList(status int, body []byte) ([]models.Model, error) {
+	raw := bytes.TrimSpace(body)
+	if len(raw) > 0 && raw[0] == '{' {
+		var env struct {
+			Code   int             `json:"code"`
+			Msg    string          `json:"msg"`
+			Data   json.RawMessage `json:"data"`
+			Models json.RawMessage `json:"models"`
+		}
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, &UpstreamError{Kind: KindUnavailable, Message: "decode model list", Err: err}
+		}
+		if env.Code != 0 {
+			ue := httpError(status, nil)
+			if k := kindFromMessage(env.Code, env.Msg); k != KindUnknown {
+				ue.Kind = k
+			} else if ue.Kind == KindUnknown {
+				ue.Kind = KindBadRequest
+			}
+			ue.Code = env.Code
+			ue.Message = env.Msg
+			return nil, ue
+		}
+		raw = bytes.TrimSpace(env.Data)
+		if len(env.Models) > 0 {
+			raw = bytes.TrimSpace(env.Models)
+		}
+		// the list may sit one level down, as data.models or data.list
+		if len(raw) > 0 && raw[0] == '{' {
+			var inner struct {
+				Models json.RawMessage `json:"models"`
+				List   json.RawMessage `json:"list"`
+			}
+			if err := json.Unmarshal(raw, &inner); err == nil {
+				raw = inner.Models
+				if len(raw) == 0 {
+					raw = inner.List
+				}
+			}
+		}
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, &UpstreamError{Kind: KindUnavailable, Message: "decode model list", Err: err}
+	}
+	out := make([]models.Model, 0, len(entries))
+	for _, e := range entries {
+		var name string
+		if json.Unmarshal(e, &name) == nil {
+			if name != "" {
+				out = append(out, models.Model{ID: name, DisplayName: name})
+			}
+			continue
+		}
+		var m modelEntry
+		if err := json.Unmarshal(e, &m); err != nil {
+			return nil, &UpstreamError{Kind: KindUnavailable, Message: "decode model list entry", Err: err}
+		}
+		if m.ID == "" {
+			m.ID = m.SiderModel
+		}
+		if m.ID == "" {
+			m.ID = m.Name
+		}
+		if m.ID == "" {
+			continue
+		}
+		if m.DisplayName == "" {
+			m.DisplayName = m.Name
+		}
+		if m.DisplayName == "" {
+			m.DisplayName = m.ID
+		}
+		out = append(out, m.Model)
+	}
+	return out, nil
+}
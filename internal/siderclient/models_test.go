@@ -0,0 +1,100 @@
+package siderclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"sider2api/internal/models"
+)
+
+func TestParseModelList(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     []models.Model
+		wantKind ErrorKind
+	}{
+		{
+			name: "bare list of names",
+			body: `["gpt-5", "", "o9"]`,
+			want: []models.Model{{ID: "gpt-5", DisplayName: "gpt-5"}, {ID: "o9", DisplayName: "o9"}},
+		},
+		{
+			name: "sider envelope with objects",
+			body: `{"code":0,"data":[{"model":"gpt-5","name":"GPT 5","supports_think":true},{"id":"o9"},{"name":"Named"},{}]}`,
+			want: []models.Model{
+				{ID: "gpt-5", DisplayName: "GPT 5", SupportsThink: true},
+				{ID: "o9", DisplayName: "o9"},
+				{ID: "Named", DisplayName: "Named"},
+			},
+		},
+		{
+			name: "list under data.models",
+			body: `{"code":0,"data":{"models":["gpt-5"]}}`,
+			want: []models.Model{{ID: "gpt-5", DisplayName: "gpt-5"}},
+		},
+		{
+			name: "list under data.list",
+			body: `{"code":0,"data":{"list":[{"id":"gpt-5","display_name":"Five"}]}}`,
+			want: []models.Model{{ID: "gpt-5", DisplayName: "Five"}},
+		},
+		{
+			name: "top-level models",
+			body: `{"models":["gpt-5"]}`,
+			want: []models.Model{{ID: "gpt-5", DisplayName: "gpt-5"}},
+		},
+		{
+			name:     "sider error",
+			body:     `{"code":401,"msg":"unauthorized"}`,
+			wantKind: KindAuth,
+		},
+		{
+			name:     "not a list",
+			body:     `"gpt-5"`,
+			wantKind: KindUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModelList(http.StatusOK, []byte(tt.body))
+			if tt.wantKind != KindUnknown {
+				if KindOf(err) != tt.wantKind {
+					t.Fatalf("err = %v, want kind %v", err, tt.wantKind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("models = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchModels(t *testing.T) {
+	var auth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"code":0,"data":["gpt-5"]}`))
+	}))
+	defer upstream.Close()
+
+	c := New("", "", time.Second, time.Second, nil)
+	if _, err := c.FetchModels(context.Background(), "token"); err == nil {
+		t.Fatal("fetch without ModelsURL succeeded")
+	}
+	c.ModelsURL = upstream.URL
+	got, err := c.FetchModels(context.Background(), "token")
+	if err != nil || len(got) != 1 || got[0].ID != "gpt-5" {
+		t.Fatalf("FetchModels = %+v, %v", got, err)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
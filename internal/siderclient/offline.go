@@ -0,0 +1,87 @@
+package siderclient
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"sider2api/internal/config"
+	"sider2api/internal/mockupstream"
+)
+
+// Offline answers calls made with test tokens from mock-upstream scenarios served
+// in-process, so SDK integrations can be exercised without reaching Sider.
+type Offline struct {
+	Tokens     map[string]bool
+	HTTPClient *http.Client
+}
+
+// NewOffline builds the offline backend from cfg; it returns nil when no offline
+// tokens are configured.
+func NewOffline(cfg config.Config, logger *slog.Logger) (*Offline, error) {
+	tokens := map[string]bool{}
+	for _, t := range strings.Split(cfg.OfflineTokens, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens[t] = true
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	scenarios, err := mockupstream.LoadScenarios(cfg.OfflineScenarios)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.OfflineDelay > 0 {
+		for i := range scenarios {
+			scenarios[i].Delay = cfg.OfflineDelay
+		}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	srv := mockupstream.New(scenarios, logger.With("backend", "offline"))
+	return &Offline{
+		Tokens: tokens,
+		HTTPClient: &http.Client{Transport: offlineTransport{
+			next:            mockupstream.Transport{Handler: srv.Handler()},
+			conversationURL: cfg.ConversationURL,
+			modelsURL:       cfg.ModelsURL,
+		}},
+	}, nil
+}
+
+// Handles reports whether calls with token go to the offline backend.
+func (o *Offline) Handles(token string) bool {
+	return o != nil && token != "" && o.Tokens[token]
+}
+
+// httpClientFor returns the HTTP client for calls made with token.
+func (c *Client) httpClientFor(token string) *http.Client {
+	if c.Offline.Handles(token) {
+		return c.Offline.HTTPClient
+	}
+	return c.HTTPClient
+}
+
+// offlineTransport maps the configured Sider URLs onto the mock endpoints, so the
+// offline backend works whatever SIDER_BASE_URL points at.
+type offlineTransport struct {
+	next            http.RoundTripper
+	conversationURL string
+	modelsURL       string
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := mockupstream.ChatPath
+	switch req.URL.String() {
+	case t.conversationURL:
+		target = mockupstream.ConversationPath
+	case t.modelsURL:
+		target = mockupstream.ModelsPath
+	}
+	r := req.Clone(req.Context())
+	r.URL.Path = target
+	r.URL.RawPath = ""
+	return t.next.RoundTrip(r)
+}
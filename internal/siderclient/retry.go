@@ -0,0 +1,97 @@
+package siderclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls automatic retries of transient upstream failures.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RetryOn lists the error kinds worth another attempt.
+	RetryOn []ErrorKind
+}
+
+// DefaultRetryPolicy retries connection failures, 5xx/504 responses and streams that
+// end before message_start.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		RetryOn:     []ErrorKind{KindUnavailable, KindTimeout, KindIncomplete},
+	}
+}
+
+// ParseErrorKinds parses a comma-separated list such as "unavailable,timeout".
+func ParseErrorKinds(s string) ([]ErrorKind, error) {
+	var out []ErrorKind
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		k, ok := kindByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown error kind %q", name)
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+func kindByName(name string) (ErrorKind, bool) {
+	for k := KindUnknown; k < kindCount; k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return KindUnknown, false
+}
+
+// shouldRetry reports whether err may be retried. Our own deadline or the caller's
+// cancellation is never retried: there is no budget left for another attempt.
+func (p RetryPolicy) shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	kind := KindOf(err)
+	for _, k := range p.RetryOn {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the attempt following attempt n (1-based):
+// exponential growth capped at MaxDelay, with jitter over the upper half.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay << (n - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,192 @@
+package siderclient
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// DefaultSSEBufferSize is the initial read buffer; it grows as needed up to the event limit.
+	DefaultSSEBufferSize = 64 << 10
+	// DefaultSSEMaxEventSize bounds one event's data so a broken stream cannot exhaust memory.
+	DefaultSSEMaxEventSize = 16 << 20
+)
+
+// ErrSSEEventTooLarge is returned when a line or event exceeds the reader's limit.
+var ErrSSEEventTooLarge = errors.New("sse event too large")
+
+// SSEEvent is one dispatched server-sent event.
+type SSEEvent struct {
+	// Event is the event type; "message" when the stream did not name one.
+	Event string
+	// ID is the last event id seen on the stream, which persists across events.
+	ID string
+	// Data is the event's data lines joined with "\n".
+	Data string
+	// Retry is the reconnection delay in milliseconds, 0 when not sent.
+	Retry int
+}
+
+// SSEReader parses a text/event-stream body following the WHATWG event stream rules:
+// CRLF, LF and CR line endings, comments, multi-line data, and the event, id and retry
+// fields. Unknown fields are ignored.
+type SSEReader struct {
+	r       *bufio.Reader
+	maxSize int
+	lastID  string
+	lines   [][]byte // lines read but not yet consumed
+	done    bool
+}
+
+// NewSSEReader reads events from r. bufSize is the initial buffer; maxSize caps a single
+// line and a single event's data. Non-positive values use the defaults.
+func NewSSEReader(r io.Reader, bufSize, maxSize int) *SSEReader {
+	if bufSize <= 0 {
+		bufSize = DefaultSSEBufferSize
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultSSEMaxEventSize
+	}
+	if bufSize > maxSize {
+		bufSize = maxSize
+	}
+	return &SSEReader{r: bufio.NewReaderSize(r, bufSize), maxSize: maxSize}
+}
+
+// Next returns the next event, or io.EOF once the stream is exhausted. A final event
+// that is not followed by a blank line is still dispatched; Sider sometimes closes the
+// connection right after the last data line.
+func (s *SSEReader) Next() (SSEEvent, error) {
+	var (
+		data    bytes.Buffer
+		hasData bool
+		evt     SSEEvent
+	)
+	for {
+		line, err := s.readLine()
+		if errors.Is(err, io.EOF) {
+			if hasData {
+				return s.dispatch(evt, data.Bytes()), nil
+			}
+			return SSEEvent{}, io.EOF
+		}
+		if err != nil {
+			return SSEEvent{}, err
+		}
+
+		if len(line) == 0 {
+			if hasData {
+				return s.dispatch(evt, data.Bytes()), nil
+			}
+			// a blank line with no data resets the event type and dispatches nothing
+			evt = SSEEvent{}
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			if len(value) > 0 && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+		switch string(field) {
+		case "data":
+			if data.Len()+len(value)+1 > s.maxSize {
+				return SSEEvent{}, fmt.Errorf("%w: data exceeds %d bytes", ErrSSEEventTooLarge, s.maxSize)
+			}
+			data.Write(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "event":
+			evt.Event = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				s.lastID = string(value)
+			}
+		case "retry":
+			if n, err := strconv.Atoi(string(value)); err == nil && n >= 0 {
+				evt.Retry = n
+			}
+		}
+	}
+}
+
+func (s *SSEReader) dispatch(evt SSEEvent, data []byte) SSEEvent {
+	evt.Data = string(bytes.TrimSuffix(data, []byte{'\n'}))
+	evt.ID = s.lastID
+	if evt.Event == "" {
+		evt.Event = "message"
+	}
+	return evt
+}
+
+// readLine returns the next line without its terminator, or io.EOF when nothing is
+// left. A final line without a terminator is still returned.
+func (s *SSEReader) readLine() ([]byte, error) {
+	for len(s.lines) == 0 {
+		if s.done {
+			return nil, io.EOF
+		}
+		if err := s.readSegment(); err != nil {
+			return nil, err
+		}
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, nil
+}
+
+// readSegment reads up to the next LF and queues the lines in it. Sider only uses LF,
+// but bare CR and CRLF endings are split here as the spec requires.
+func (s *SSEReader) readSegment() error {
+	var seg []byte
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		if len(seg)+len(chunk) > s.maxSize+2 {
+			return fmt.Errorf("%w: line exceeds %d bytes", ErrSSEEventTooLarge, s.maxSize)
+		}
+		seg = append(seg, chunk...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			s.done = true
+		}
+		break
+	}
+	if len(seg) == 0 {
+		return nil
+	}
+
+	terminated := bytes.HasSuffix(seg, []byte{'\n'})
+	seg = bytes.TrimSuffix(seg, []byte{'\n'})
+	if terminated {
+		seg = bytes.TrimSuffix(seg, []byte{'\r'})
+	} else if bytes.HasSuffix(seg, []byte{'\r'}) {
+		seg = seg[:len(seg)-1]
+		terminated = true
+	}
+	for {
+		i := bytes.IndexByte(seg, '\r')
+		if i < 0 {
+			break
+		}
+		s.lines = append(s.lines, seg[:i])
+		seg = seg[i+1:]
+	}
+	if len(seg) > 0 || terminated {
+		s.lines = append(s.lines, seg)
+	}
+	return nil
+}
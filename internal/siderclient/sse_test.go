@@ -0,0 +1,129 @@
+package siderclient
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readEvents drains r and returns the events before the first error.
+func readEvents(r *SSEReader) ([]SSEEvent, error) {
+	var events []SSEEvent
+	for {
+		evt, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			return events, err
+		}
+		events = append(events, evt)
+	}
+}
+
+func TestSSEReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		maxSize int
+		want    []SSEEvent
+		wantErr error
+	}{
+		{
+			name:   "LF",
+			stream: "data: one\n\ndata: two\n\n",
+			want:   []SSEEvent{{Event: "message", Data: "one"}, {Event: "message", Data: "two"}},
+		},
+		{
+			name:   "CRLF",
+			stream: "data: one\r\n\r\ndata: two\r\n\r\n",
+			want:   []SSEEvent{{Event: "message", Data: "one"}, {Event: "message", Data: "two"}},
+		},
+		{
+			name:   "CR",
+			stream: "data: one\r\rdata: two\r\r",
+			want:   []SSEEvent{{Event: "message", Data: "one"}, {Event: "message", Data: "two"}},
+		},
+		{
+			name:   "mixed endings",
+			stream: "data: one\r\ndata: two\rdata: three\n\r\n",
+			want:   []SSEEvent{{Event: "message", Data: "one\ntwo\nthree"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: first\ndata:second\ndata\ndata:  indented\n\n",
+			want:   []SSEEvent{{Event: "message", Data: "first\nsecond\n\n indented"}},
+		},
+		{
+			name:   "event id and retry",
+			stream: "event: update\nid: 7\nretry: 1500\ndata: {}\n\ndata: next\n\n",
+			want: []SSEEvent{
+				{Event: "update", ID: "7", Retry: 1500, Data: "{}"},
+				{Event: "message", ID: "7", Data: "next"},
+			},
+		},
+		{
+			name:   "invalid retry and id with NUL are ignored",
+			stream: "id: 1\ndata: a\n\nretry: soon\nid: x\x00y\ndata: b\n\n",
+			want:   []SSEEvent{{Event: "message", ID: "1", Data: "a"}, {Event: "message", ID: "1", Data: "b"}},
+		},
+		{
+			name:   "empty id resets last id",
+			stream: "id: 1\ndata: a\n\nid\ndata: b\n\n",
+			want:   []SSEEvent{{Event: "message", ID: "1", Data: "a"}, {Event: "message", Data: "b"}},
+		},
+		{
+			name:   "comments and unknown fields",
+			stream: ": keep-alive\n:\nfoo: bar\ndata: one\n: inside\n\n",
+			want:   []SSEEvent{{Event: "message", Data: "one"}},
+		},
+		{
+			name:   "blank line without data dispatches nothing",
+			stream: "event: ping\n\ndata: one\n\n",
+			want:   []SSEEvent{{Event: "message", Data: "one"}},
+		},
+		{
+			name:   "final event without trailing blank line",
+			stream: "data: one\n\ndata: [DONE]",
+			want:   []SSEEvent{{Event: "message", Data: "one"}, {Event: "message", Data: "[DONE]"}},
+		},
+		{
+			name:   "final event ending in a single newline",
+			stream: "data: last\n",
+			want:   []SSEEvent{{Event: "message", Data: "last"}},
+		},
+		{
+			name:    "event data over maxSize",
+			stream:  "data: 0123456789\ndata: 0123456789\ndata: 0123456789\n\n",
+			maxSize: 24,
+			wantErr: ErrSSEEventTooLarge,
+		},
+		{
+			name:    "line over maxSize",
+			stream:  "data: " + strings.Repeat("x", 64) + "\n\n",
+			maxSize: 32,
+			wantErr: ErrSSEEventTooLarge,
+		},
+		{
+			name:   "empty stream",
+			stream: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a tiny buffer makes lines span several reads
+			got, err := readEvents(NewSSEReader(strings.NewReader(tt.stream), 16, tt.maxSize))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package siderclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"sider2api/internal/config"
+)
+
+// NewTransport builds the upstream HTTP transport from cfg: proxy, extra CA
+// certificates, connection pooling, timeouts and HTTP/2. Invalid settings are
+// reported here so they fail at startup rather than on the first request.
+func NewTransport(cfg config.Config) (*http.Transport, error) {
+	if cfg.UpstreamDialTimeout < 0 || cfg.UpstreamTLSHandshakeTimeout < 0 || cfg.UpstreamIdleConnTimeout < 0 {
+		return nil, errors.New("upstream timeouts must not be negative")
+	}
+	if cfg.UpstreamMaxIdleConns < 0 || cfg.UpstreamMaxIdleConnsPerHost < 0 {
+		return nil, errors.New("upstream idle connection limits must not be negative")
+	}
+
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   cfg.UpstreamDialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          cfg.UpstreamMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.UpstreamMaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.UpstreamIdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.UpstreamTLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     cfg.UpstreamHTTP2,
+	}
+	if !cfg.UpstreamHTTP2 {
+		// a non-nil empty map disables the transport's automatic HTTP/2 upgrade
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	if cfg.UpstreamProxy != "" {
+		proxyURL, err := parseProxyURL(cfg.UpstreamProxy)
+		if err != nil {
+			return nil, err
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.UpstreamCABundle != "" {
+		pool, err := loadCABundle(cfg.UpstreamCABundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return t, nil
+}
+
+// parseProxyURL accepts http, https, socks5 and socks5h proxy URLs.
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("upstream proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("upstream proxy: unsupported scheme %q (want http, https, socks5 or socks5h)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("upstream proxy: missing host in %q", u.Redacted())
+	}
+	return u, nil
+}
+
+// loadCABundle adds the PEM certificates at path to the system roots.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("upstream CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("upstream CA bundle: no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// redactURL hides the password in a proxy URL for logging.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,304 @@
+package siderclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// VCR modes. Off talks to Sider directly; record passes requests through and saves
+// each exchange; replay serves saved exchanges and never touches the network.
+const (
+	VCROff    = "off"
+	VCRRecord = "record"
+	VCRReplay = "replay"
+)
+
+// redacted replaces credentials in saved fixtures.
+const redacted = "REDACTED"
+
+// maxRecordedBody bounds how much unread body is drained for a fixture on Close.
+const maxRecordedBody = 32 << 20
+
+// Fixture is one recorded upstream exchange.
+type Fixture struct {
+	Key        string          `json:"key"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Request    FixtureRequest  `json:"request"`
+	Response   FixtureResponse `json:"response"`
+}
+
+// FixtureRequest is the recorded request. Body is the JSON payload as sent, with
+// the token removed.
+type FixtureRequest struct {
+	Method  string          `json:"method"`
+	URL     string          `json:"url"`
+	Headers http.Header     `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// FixtureResponse is the recorded response. Body is the raw bytes, e.g. the SSE stream.
+type FixtureResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body"`
+}
+
+// VCR is an http.RoundTripper that records upstream exchanges to a fixtures
+// directory or replays them from it. Requests are matched on method, URL path and
+// the canonical JSON body, so a replay needs the same conversation ids as the
+// recording; a scripted session replays turn by turn.
+type VCR struct {
+	Mode   string
+	Dir    string
+	Next   http.RoundTripper
+	Logger *slog.Logger
+}
+
+// NewVCR validates mode and prepares dir. next is only used when recording.
+func NewVCR(mode, dir string, next http.RoundTripper, logger *slog.Logger) (*VCR, error) {
+	switch mode {
+	case VCRRecord, VCRReplay:
+	default:
+		return nil, fmt.Errorf("unknown vcr mode %q (want off, record or replay)", mode)
+	}
+	if dir == "" {
+		return nil, errors.New("vcr fixtures directory is required")
+	}
+	if mode == VCRRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("vcr: %w", err)
+		}
+	} else if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("vcr: %w", err)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &VCR{Mode: mode, Dir: dir, Next: next, Logger: logger}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (v *VCR) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := fixtureKey(req.Method, req.URL.Path, body)
+	file := filepath.Join(v.Dir, fixtureName(req.URL.Path, key))
+
+	if v.Mode == VCRReplay {
+		return v.replay(req, key, file)
+	}
+
+	resp, err := v.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	token := bearerToken(req.Header.Get("Authorization"))
+	fx := Fixture{
+		Key:        key,
+		RecordedAt: time.Now().UTC(),
+		Request: FixtureRequest{
+			Method:  req.Method,
+			URL:     req.URL.Redacted(),
+			Headers: redactHeaders(req.Header, token),
+			Body:    redactJSON(body, token),
+		},
+		Response: FixtureResponse{Status: resp.StatusCode, Headers: redactHeaders(resp.Header, token)},
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		save: func(raw []byte) {
+			if resp.StatusCode >= 300 && hasSuccessFixture(file) {
+				// a failed retry of the same request must not replace a good recording
+				v.Logger.Info("vcr: kept existing fixture over failed response", "file", file, "status", resp.StatusCode)
+				return
+			}
+			fx.Response.Body = redactString(string(raw), token)
+			if err := writeFixture(file, fx); err != nil {
+				v.Logger.Error("vcr: write fixture failed", "file", file, "error", err)
+				return
+			}
+			v.Logger.Info("vcr: recorded upstream exchange", "file", file, "status", resp.StatusCode)
+		},
+		discard: func() {
+			v.Logger.Debug("vcr: response not fully read, not recorded", "url", req.URL.Redacted())
+		},
+	}
+	return resp, nil
+}
+
+func (v *VCR) replay(req *http.Request, key, file string) (*http.Response, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("vcr: no fixture for %s %s (key %s) in %s", req.Method, req.URL.Path, key[:12], v.Dir)
+		}
+		return nil, fmt.Errorf("vcr: %w", err)
+	}
+	var fx Fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf("vcr: %s: %w", file, err)
+	}
+	v.Logger.Debug("vcr: replaying upstream exchange", "file", file, "status", fx.Response.Status)
+	header := fx.Response.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fx.Response.Status, http.StatusText(fx.Response.Status)),
+		StatusCode:    fx.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fx.Response.Body)),
+		ContentLength: int64(len(fx.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// recordingBody captures what the client reads and saves it once the body is
+// complete. The client stops early on error bodies and at [DONE], so the rest is
+// drained on Close; a stream cut off by cancellation fails to drain and is not saved.
+type recordingBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	eof     bool
+	done    bool
+	save    func(raw []byte)
+	discard func()
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if !b.done {
+		b.done = true
+		if !b.eof {
+			n, err := io.Copy(&b.buf, io.LimitReader(b.ReadCloser, maxRecordedBody))
+			b.eof = err == nil && n < maxRecordedBody
+		}
+		if b.eof {
+			b.save(b.buf.Bytes())
+		} else {
+			b.discard()
+		}
+	}
+	return b.ReadCloser.Close()
+}
+
+// fixtureKey hashes what identifies a request. JSON bodies are re-encoded so key
+// order and whitespace do not matter.
+func fixtureKey(method, urlPath string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + urlPath + "\n"))
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		canonical, _ := json.Marshal(v)
+		h.Write(canonical)
+	} else {
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// fixtureName is a readable, stable file name: the last URL path segment plus the key.
+func fixtureName(urlPath, key string) string {
+	base := unsafeName.ReplaceAllString(path.Base(urlPath), "_")
+	if base == "" || base == "_" {
+		base = "request"
+	}
+	return base + "-" + key[:16] + ".json"
+}
+
+func hasSuccessFixture(file string) bool {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	var fx Fixture
+	return json.Unmarshal(data, &fx) == nil && fx.Response.Status >= 200 && fx.Response.Status < 300
+}
+
+func writeFixture(file string, fx Fixture) error {
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func bearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return strings.TrimSpace(header)
+}
+
+// sensitiveHeaders never reach a fixture, whatever their value.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+func redactHeaders(h http.Header, token string) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	for name, values := range out {
+		for i, v := range values {
+			values[i] = redactString(v, token)
+		}
+		out[name] = values
+	}
+	return out
+}
+
+func redactString(s, token string) string {
+	// very short values such as "dummy" would mangle unrelated text
+	if len(token) < 8 {
+		return s
+	}
+	return strings.ReplaceAll(s, token, redacted)
+}
+
+// redactJSON returns body with the token removed, or nil when body is not JSON.
+func redactJSON(body []byte, token string) json.RawMessage {
+	if len(body) == 0 || !json.Valid(body) {
+		return nil
+	}
+	return json.RawMessage(redactString(string(body), token))
+}
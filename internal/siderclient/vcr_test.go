@@ -0,0 +1,250 @@
+package siderclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sider2api/internal/session"
+	"sider2api/pkg/types"
+)
+
+// replayDir holds fixtures recorded from testdata/scenarios.yaml.
+const replayDir = "testdata/fixtures"
+
+// replayClient returns a client that serves every chat call from replayDir.
+func replayClient(t *testing.T, baseURL string) *Client {
+	t.Helper()
+	vcr, err := NewVCR(VCRReplay, replayDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(baseURL, "", 10*time.Second, 10*time.Second, session.NewSiderSessionManager(time.Hour, ""))
+	c.HTTPClient.Transport = vcr
+	c.Retry.MaxAttempts = 1
+	return c
+}
+
+// fixtureRequest loads a fixture and returns the chat request and URL it was
+// recorded for.
+func fixtureRequest(t *testing.T, name string) (types.SiderRequest, string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(replayDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fx Fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		t.Fatal(err)
+	}
+	var req types.SiderRequest
+	if err := json.Unmarshal(fx.Request.Body, &req); err != nil {
+		t.Fatal(err)
+	}
+	return req, fx.Request.URL
+}
+
+func TestVCRReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		text      string
+		reasoning string
+		tools     []string
+		errKind   ErrorKind
+	}{
+		{
+			name:    "plain reply",
+			fixture: "completions-8f5e42fb074ff138.json",
+			text:    "Mock reply from claude-haiku-4.5. You said: Hello there",
+		},
+		{
+			name:      "reasoning and search",
+			fixture:   "completions-77ebd6f9cae81eca.json",
+			text:      "Lines may end in CR, LF or CRLF.",
+			reasoning: "I should look this up. A search will settle it.",
+			tools:     []string{"search"},
+		},
+		{
+			name:    "in-stream error",
+			fixture: "completions-3f392c2b10f5f323.json",
+			text:    "The answer starts fine, then ",
+			errKind: KindStream,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, baseURL := fixtureRequest(t, tt.fixture)
+			c := replayClient(t, baseURL)
+
+			events := 0
+			resp, err := c.ChatStream(context.Background(), req, "fixture-token", func(types.SiderSSEResponse, types.SiderParsedResponse) {
+				events++
+			})
+
+			if tt.errKind == KindUnknown {
+				if err != nil {
+					t.Fatalf("ChatStream: %v", err)
+				}
+			} else {
+				var ue *UpstreamError
+				if !errors.As(err, &ue) {
+					t.Fatalf("err = %v, want *UpstreamError", err)
+				}
+				if ue.Kind != tt.errKind || !ue.InStream {
+					t.Fatalf("err kind = %v in stream = %v, want %v in stream", ue.Kind, ue.InStream, tt.errKind)
+				}
+			}
+			if events == 0 {
+				t.Error("callback never called")
+			}
+			if got := strings.Join(resp.TextParts, ""); got != tt.text {
+				t.Errorf("text = %q, want %q", got, tt.text)
+			}
+			if got := strings.Join(resp.ReasoningParts, ""); got != tt.reasoning {
+				t.Errorf("reasoning = %q, want %q", got, tt.reasoning)
+			}
+			var tools []string
+			for _, r := range resp.ToolResults {
+				tools = append(tools, r.ToolName)
+			}
+			if !reflect.DeepEqual(tools, tt.tools) {
+				t.Errorf("tools = %v, want %v", tools, tt.tools)
+			}
+			if resp.ConversationID == "" || resp.MessageIDs == nil {
+				t.Errorf("conversation ids missing: cid %q ids %v", resp.ConversationID, resp.MessageIDs)
+			}
+			if _, ok := c.Sessions.Snapshot(resp.ConversationID); !ok {
+				t.Errorf("session %q not saved", resp.ConversationID)
+			}
+		})
+	}
+}
+
+func TestVCRReplayMissingFixture(t *testing.T) {
+	req, baseURL := fixtureRequest(t, "completions-8f5e42fb074ff138.json")
+	req.Model = "not-recorded"
+	c := replayClient(t, baseURL)
+	if _, err := c.Chat(context.Background(), req, "fixture-token"); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Fatalf("err = %v, want missing fixture", err)
+	}
+}
+
+const recordToken = "secret-token-0123456789"
+
+// vcrClient returns an HTTP client that records through or replays from dir.
+func vcrClient(t *testing.T, mode, dir string) *http.Client {
+	t.Helper()
+	vcr, err := NewVCR(mode, dir, http.DefaultTransport, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: vcr}
+}
+
+// post sends body with the record token and returns the status and response body.
+func post(t *testing.T, client *http.Client, url, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+recordToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestVCRRecordThenReplay(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(int(status.Load()))
+		io.WriteString(w, "data: {\"text\":\"hi, your token is "+recordToken+"\"}\n\ndata: [DONE]\n\n")
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+	url := upstream.URL + "/api/chat/v1/completions"
+
+	recorder := vcrClient(t, VCRRecord, dir)
+	_, recorded := post(t, recorder, url, `{"model":"m","prompt":"`+recordToken+`","cid":""}`)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "completions-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("fixtures = %v, want one", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), recordToken) || strings.Contains(string(data), "session=abc") {
+		t.Fatalf("fixture leaks credentials:\n%s", data)
+	}
+
+	// a failed retry of the same request keeps the good recording
+	status.Store(http.StatusBadGateway)
+	post(t, recorder, url, `{"model":"m","prompt":"`+recordToken+`","cid":""}`)
+
+	// key order and whitespace do not change the match
+	replayer := vcrClient(t, VCRReplay, dir)
+	code, replayed := post(t, replayer, url, `{ "cid": "", "prompt": "`+recordToken+`", "model": "m" }`)
+	if code != http.StatusOK {
+		t.Fatalf("replayed status = %d, want 200", code)
+	}
+	if want := strings.ReplaceAll(recorded, recordToken, redacted); replayed != want {
+		t.Errorf("replayed body = %q, want %q", replayed, want)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"model":"other"}`))
+	if _, err := replayer.Do(req); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Errorf("unrecorded request err = %v, want no fixture", err)
+	}
+}
+
+func TestVCRSkipsUnfinishedResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+	dir := t.TempDir()
+
+	req, _ := http.NewRequest(http.MethodPost, upstream.URL+"/api/chat/v1/completions", strings.NewReader(`{}`))
+	resp, err := vcrClient(t, VCRRecord, dir).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 8)
+	resp.Body.Read(buf)
+	// closing mid-stream, as a canceled request does, must not save a truncated fixture
+	upstream.CloseClientConnections()
+	resp.Body.Close()
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Fatalf("fixtures = %v, want none", files)
+	}
+}
+
+func TestNewVCR(t *testing.T) {
+	if _, err := NewVCR("rewind", t.TempDir(), nil, nil); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	if _, err := NewVCR(VCRReplay, "", nil, nil); err == nil {
+		t.Error("empty directory accepted")
+	}
+	if _, err := NewVCR(VCRReplay, filepath.Join(t.TempDir(), "missing"), nil, nil); err == nil {
+		t.Error("missing replay directory accepted")
+	}
+}
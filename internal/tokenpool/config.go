@@ -0,0 +1,63 @@
+package tokenpool
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+
+    "sider2api/internal/config"
+)
+
+// FromConfig builds the pool from SIDER_TOKENS / SIDER_TOKENS_FILE. It returns nil
+// when neither is set, in which case the single SiderAPIToken is used as before.
+// The env token, when set, joins the pool.
+func FromConfig(cfg config.Config) (*Pool, error) {
+    var entries []Entry
+    for _, t := range strings.Split(cfg.SiderTokens, ",") {
+        if t = strings.TrimSpace(t); t != "" {
+            entries = append(entries, Entry{Token: t})
+        }
+    }
+    if cfg.SiderTokensFile != "" {
+        fromFile, err := LoadFile(cfg.SiderTokensFile)
+        if err != nil {
+            return nil, err
+        }
+        entries = append(entries, fromFile...)
+    }
+    if len(entries) == 0 {
+        return nil, nil
+    }
+    if cfg.SiderAPIToken != "" {
+        entries = append([]Entry{{Name: "env", Token: cfg.SiderAPIToken}}, entries...)
+    }
+    return New(entries, Strategy(cfg.TokenStrategy), cfg.TokenCooldown, cfg.SiderSessionMaxAge)
+}
+
+// LoadFile reads tokens from a JSON array of entries (*.json) or a plain text
+// file with one token per line and '#' comments.
+func LoadFile(path string) ([]Entry, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("read tokens file: %w", err)
+    }
+    if strings.HasSuffix(strings.ToLower(path), ".json") {
+        var entries []Entry
+        if err := json.Unmarshal(data, &entries); err != nil {
+            return nil, fmt.Errorf("parse tokens file: %w", err)
+        }
+        return entries, nil
+    }
+    var entries []Entry
+    scanner := bufio.NewScanner(strings.NewReader(string(data)))
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        entries = append(entries, Entry{Token: line})
+    }
+    return entries, scanner.Err()
+}
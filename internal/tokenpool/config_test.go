@@ -0,0 +1,52 @@
+package tokenpool
+
+import (
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+func TestLoadFile(t *testing.T) {
+    tests := []struct {
+        name    string
+        file    string
+        content string
+        want    []Entry
+        wantErr bool
+    }{
+        {
+            name:    "plain text",
+            file:    "tokens.txt",
+            content: "# pool\ntoken-a\n\n  token-b  \n",
+            want:    []Entry{{Token: "token-a"}, {Token: "token-b"}},
+        },
+        {
+            name:    "json",
+            file:    "tokens.JSON",
+            content: `[{"name":"main","token":"token-a","profile":"web"},{"token":"token-b"}]`,
+            want:    []Entry{{Name: "main", Token: "token-a", Profile: "web"}, {Token: "token-b"}},
+        },
+        {
+            name:    "invalid json",
+            file:    "tokens.json",
+            content: `{"token":"x"}`,
+            wantErr: true,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            path := filepath.Join(t.TempDir(), tt.file)
+            if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+                t.Fatal(err)
+            }
+            got, err := LoadFile(path)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+            }
+            if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("entries = %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
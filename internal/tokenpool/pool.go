@@ -0,0 +1,322 @@
+package tokenpool
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Strategy selects which token serves the next request.
+type Strategy string
+
+const (
+    RoundRobin Strategy = "round-robin"
+    LeastUsed  Strategy = "least-used"
+    // Sticky keeps every conversation on the token that created it, since upstream
+    // CIDs belong to one Sider account. New conversations go to the least used token.
+    Sticky Strategy = "sticky"
+)
+
+// ErrNoHealthyToken is returned when every candidate token is cooling down or excluded.
+var ErrNoHealthyToken = errors.New("no healthy Sider token available")
+
+// Entry is one configured Sider token.
+type Entry struct {
+    Name    string `json:"name,omitempty"`
+    Token   string `json:"token"`
+    Profile string `json:"profile,omitempty"`
+}
+
+// Health is the admin view of one token; the secret itself is masked.
+type Health struct {
+    Name          string     `json:"name"`
+    Token         string     `json:"token"`
+    Healthy       bool       `json:"healthy"`
+    Uses          int64      `json:"uses"`
+    Failures      int64      `json:"failures"`
+    InFlight      int        `json:"in_flight"`
+    Conversations int        `json:"conversations"`
+    LastUsed      *time.Time `json:"last_used,omitempty"`
+    LastError     string     `json:"last_error,omitempty"`
+    LastErrorAt   *time.Time `json:"last_error_at,omitempty"`
+    CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+}
+
+type tokenState struct {
+    entry         Entry
+    fingerprint   string
+    uses          int64
+    failures      int64
+    inFlight      int
+    lastUsed      time.Time
+    lastError     string
+    lastErrorAt   time.Time
+    cooldownUntil time.Time
+}
+
+type binding struct {
+    token    string
+    lastUsed time.Time
+}
+
+// Pool hands out Sider tokens and cools down the ones upstream rejects.
+type Pool struct {
+    mu       sync.Mutex
+    tokens   []*tokenState
+    byToken  map[string]*tokenState
+    strategy Strategy
+    cooldown time.Duration
+    next     int
+    bindings map[string]binding
+    bindTTL  time.Duration
+    lookup   func(cid string) string
+}
+
+// New builds a pool. bindTTL bounds how long an idle sticky CID binding is kept.
+func New(entries []Entry, strategy Strategy, cooldown, bindTTL time.Duration) (*Pool, error) {
+    switch strategy {
+    case RoundRobin, LeastUsed, Sticky:
+    case "":
+        strategy = RoundRobin
+    default:
+        return nil, fmt.Errorf("unknown token strategy %q (want round-robin, least-used or sticky)", strategy)
+    }
+    p := &Pool{
+        byToken:  map[string]*tokenState{},
+        strategy: strategy,
+        cooldown: cooldown,
+        bindings: map[string]binding{},
+        bindTTL:  bindTTL,
+    }
+    for i, e := range entries {
+        if e.Token == "" {
+            continue
+        }
+        if _, dup := p.byToken[e.Token]; dup {
+            continue
+        }
+        if e.Name == "" {
+            e.Name = fmt.Sprintf("token-%d", i+1)
+        }
+        st := &tokenState{entry: e, fingerprint: Fingerprint(e.Token)}
+        p.tokens = append(p.tokens, st)
+        p.byToken[e.Token] = st
+    }
+    if len(p.tokens) == 0 {
+        return nil, errors.New("token pool is empty")
+    }
+    return p, nil
+}
+
+// Size returns the number of tokens in the pool.
+func (p *Pool) Size() int {
+    return len(p.tokens)
+}
+
+// Entries returns the configured tokens.
+func (p *Pool) Entries() []Entry {
+    out := make([]Entry, len(p.tokens))
+    for i, st := range p.tokens {
+        out[i] = st.entry
+    }
+    return out
+}
+
+// Strategy returns the configured selection strategy.
+func (p *Pool) Strategy() Strategy {
+    return p.strategy
+}
+
+// SetBindingLookup sets where sticky mode finds the token fingerprint a conversation
+// was bound to before a restart. It must be called before the pool is used.
+func (p *Pool) SetBindingLookup(lookup func(cid string) string) {
+    p.lookup = lookup
+}
+
+// Pick returns a token for a request on cid, skipping tokens in exclude. The caller
+// must pass the token to Done once the request finishes.
+func (p *Pool) Pick(cid string, exclude map[string]bool) (Entry, error) {
+    var persisted string
+    if p.strategy == Sticky && cid != "" && p.lookup != nil {
+        persisted = p.lookup(cid)
+    }
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    now := time.Now()
+
+    if p.strategy == Sticky && cid != "" {
+        if _, ok := p.bindings[cid]; !ok && persisted != "" {
+            p.restoreBinding(cid, persisted, now)
+        }
+        if b, ok := p.bindings[cid]; ok {
+            st := p.byToken[b.token]
+            if st == nil || exclude[b.token] || st.cooldownUntil.After(now) {
+                return Entry{}, fmt.Errorf("%w: conversation %s is bound to %s", ErrNoHealthyToken, cid, nameOf(st))
+            }
+            p.bindings[cid] = binding{token: b.token, lastUsed: now}
+            return p.take(st, now), nil
+        }
+    }
+
+    var candidates []*tokenState
+    for _, st := range p.tokens {
+        if exclude[st.entry.Token] || st.cooldownUntil.After(now) {
+            continue
+        }
+        candidates = append(candidates, st)
+    }
+    if len(candidates) == 0 {
+        return Entry{}, ErrNoHealthyToken
+    }
+
+    switch p.strategy {
+    case RoundRobin:
+        // advance the cursor past tokens that are not candidates
+        for i := 0; i < len(p.tokens); i++ {
+            st := p.tokens[(p.next+i)%len(p.tokens)]
+            if exclude[st.entry.Token] || st.cooldownUntil.After(now) {
+                continue
+            }
+            p.next = (p.next + i + 1) % len(p.tokens)
+            return p.take(st, now), nil
+        }
+        return Entry{}, ErrNoHealthyToken
+    default:
+        sort.SliceStable(candidates, func(i, j int) bool {
+            if candidates[i].inFlight != candidates[j].inFlight {
+                return candidates[i].inFlight < candidates[j].inFlight
+            }
+            return candidates[i].uses < candidates[j].uses
+        })
+        return p.take(candidates[0], now), nil
+    }
+}
+
+// restoreBinding rebuilds the binding of cid from a persisted token fingerprint. A
+// fingerprint of a token no longer in the pool still binds, so the conversation fails
+// instead of moving to an account that does not hold it.
+func (p *Pool) restoreBinding(cid, fingerprint string, now time.Time) {
+    token := "removed:" + fingerprint
+    for _, st := range p.tokens {
+        if st.fingerprint == fingerprint {
+            token = st.entry.Token
+            break
+        }
+    }
+    p.bindings[cid] = binding{token: token, lastUsed: now}
+}
+
+func (p *Pool) take(st *tokenState, now time.Time) Entry {
+    st.uses++
+    st.inFlight++
+    st.lastUsed = now
+    return st.entry
+}
+
+// Done records the outcome of a request made with token. A non-empty failure with
+// cool set puts the token on cooldown (auth or quota errors).
+func (p *Pool) Done(token string, failure string, cool bool) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    st := p.byToken[token]
+    if st == nil {
+        return
+    }
+    if st.inFlight > 0 {
+        st.inFlight--
+    }
+    if failure == "" {
+        return
+    }
+    st.failures++
+    st.lastError = failure
+    st.lastErrorAt = time.Now()
+    if cool && p.cooldown > 0 {
+        st.cooldownUntil = st.lastErrorAt.Add(p.cooldown)
+    }
+}
+
+// Bind pins cid to token in sticky mode so follow-up turns use the same account.
+func (p *Pool) Bind(cid, token string) {
+    if p.strategy != Sticky || cid == "" {
+        return
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if _, ok := p.byToken[token]; !ok {
+        return
+    }
+    now := time.Now()
+    p.bindings[cid] = binding{token: token, lastUsed: now}
+    if p.bindTTL > 0 {
+        for k, b := range p.bindings {
+            if now.Sub(b.lastUsed) > p.bindTTL {
+                delete(p.bindings, k)
+            }
+        }
+    }
+}
+
+// Health returns per-token status for diagnostics.
+func (p *Pool) Health() []Health {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    now := time.Now()
+    convs := map[string]int{}
+    for _, b := range p.bindings {
+        convs[b.token]++
+    }
+    out := make([]Health, 0, len(p.tokens))
+    for _, st := range p.tokens {
+        h := Health{
+            Name:          st.entry.Name,
+            Token:         Mask(st.entry.Token),
+            Healthy:       !st.cooldownUntil.After(now),
+            Uses:          st.uses,
+            Failures:      st.failures,
+            InFlight:      st.inFlight,
+            Conversations: convs[st.entry.Token],
+            LastUsed:      timePtr(st.lastUsed),
+            LastError:     st.lastError,
+            LastErrorAt:   timePtr(st.lastErrorAt),
+        }
+        if st.cooldownUntil.After(now) {
+            h.CooldownUntil = timePtr(st.cooldownUntil)
+        }
+        out = append(out, h)
+    }
+    return out
+}
+
+// Fingerprint identifies token without revealing it, for persisting bindings.
+func Fingerprint(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:8])
+}
+
+// Mask shortens a secret to its first and last four characters.
+func Mask(token string) string {
+    if len(token) <= 12 {
+        return "****"
+    }
+    return token[:4] + "…" + token[len(token)-4:]
+}
+
+func timePtr(t time.Time) *time.Time {
+    if t.IsZero() {
+        return nil
+    }
+    return &t
+}
+
+func nameOf(st *tokenState) string {
+    if st == nil {
+        return "a removed token"
+    }
+    return st.entry.Name
+}
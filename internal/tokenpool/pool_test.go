@@ -0,0 +1,191 @@
+package tokenpool
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func testPool(t *testing.T, strategy Strategy) *Pool {
+    t.Helper()
+    p, err := New([]Entry{{Name: "a", Token: "token-a"}, {Name: "b", Token: "token-b"}, {Name: "c", Token: "token-c"}}, strategy, time.Minute, time.Hour)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+// pick picks for cid and releases the token at once, returning its name.
+func pick(t *testing.T, p *Pool, cid string, exclude map[string]bool) string {
+    t.Helper()
+    e, err := p.Pick(cid, exclude)
+    if err != nil {
+        t.Fatalf("Pick(%q): %v", cid, err)
+    }
+    p.Done(e.Token, "", false)
+    return e.Name
+}
+
+func TestNew(t *testing.T) {
+    p, err := New([]Entry{{Token: "x"}, {Token: "x"}, {Token: ""}, {Token: "y"}}, "", 0, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if p.Size() != 2 || p.Strategy() != RoundRobin {
+        t.Fatalf("size %d strategy %q, want 2 round-robin", p.Size(), p.Strategy())
+    }
+    if names := []string{p.Entries()[0].Name, p.Entries()[1].Name}; names[0] != "token-1" || names[1] != "token-4" {
+        t.Fatalf("default names = %v", names)
+    }
+    if _, err := New([]Entry{{Token: ""}}, RoundRobin, 0, 0); err == nil {
+        t.Error("empty pool accepted")
+    }
+    if _, err := New([]Entry{{Token: "x"}}, "random", 0, 0); err == nil {
+        t.Error("unknown strategy accepted")
+    }
+}
+
+func TestPickRoundRobin(t *testing.T) {
+    p := testPool(t, RoundRobin)
+    tests := []struct {
+        name    string
+        cool    string
+        exclude map[string]bool
+        want    []string
+    }{
+        {name: "rotates", want: []string{"a", "b", "c", "a"}},
+        {name: "skips excluded", exclude: map[string]bool{"token-c": true}, want: []string{"b", "a", "b"}},
+        {name: "skips cooling", cool: "token-a", want: []string{"c", "b", "c"}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if tt.cool != "" {
+                p.Done(tt.cool, "quota exhausted", true)
+            }
+            var got []string
+            for range tt.want {
+                got = append(got, pick(t, p, "", tt.exclude))
+            }
+            for i := range got {
+                if got[i] != tt.want[i] {
+                    t.Fatalf("picks = %v, want %v", got, tt.want)
+                }
+            }
+        })
+    }
+}
+
+func TestPickLeastUsed(t *testing.T) {
+    p := testPool(t, LeastUsed)
+    // a holds a request in flight, so the others go first
+    busy, _ := p.Pick("", nil)
+    if busy.Name != "a" {
+        t.Fatalf("first pick = %q, want a", busy.Name)
+    }
+    if got := pick(t, p, "", nil); got != "b" {
+        t.Fatalf("pick with a busy = %q, want b", got)
+    }
+    if got := pick(t, p, "", nil); got != "c" {
+        t.Fatalf("pick = %q, want the least used c", got)
+    }
+    p.Done(busy.Token, "", false)
+    if got := pick(t, p, "", nil); got != "a" {
+        t.Fatalf("pick after release = %q, want a", got)
+    }
+
+    p.Done("token-b", "auth failed", true)
+    for i := 0; i < 4; i++ {
+        if got := pick(t, p, "", nil); got == "b" {
+            t.Fatal("picked a cooling token")
+        }
+    }
+    if _, err := p.Pick("", map[string]bool{"token-a": true, "token-c": true}); !errors.Is(err, ErrNoHealthyToken) {
+        t.Fatalf("err = %v, want ErrNoHealthyToken", err)
+    }
+}
+
+func TestPickSticky(t *testing.T) {
+    p := testPool(t, Sticky)
+    first := pick(t, p, "cid-1", nil)
+    p.Bind("cid-1", "token-"+first)
+    for i := 0; i < 3; i++ {
+        if got := pick(t, p, "cid-1", nil); got != first {
+            t.Fatalf("bound conversation moved from %s to %s", first, got)
+        }
+    }
+    if got := pick(t, p, "cid-2", nil); got == first {
+        t.Fatalf("new conversation went to the busiest token %s", got)
+    }
+    if h := health(p, first); h.Conversations != 1 {
+        t.Fatalf("conversations on %s = %d, want 1", first, h.Conversations)
+    }
+
+    // a cooling bound token fails the conversation instead of moving it
+    p.Done("token-"+first, "quota exhausted", true)
+    if _, err := p.Pick("cid-1", nil); !errors.Is(err, ErrNoHealthyToken) {
+        t.Fatalf("err = %v, want ErrNoHealthyToken", err)
+    }
+    if _, err := p.Pick("cid-1", map[string]bool{"token-" + first: true}); !errors.Is(err, ErrNoHealthyToken) {
+        t.Fatalf("err with the bound token excluded = %v, want ErrNoHealthyToken", err)
+    }
+    if got := pick(t, p, "", nil); got == first {
+        t.Fatal("unbound request picked the cooling token")
+    }
+}
+
+func TestPickStickyRestoresBinding(t *testing.T) {
+    persisted := map[string]string{
+        "cid-b":       Fingerprint("token-b"),
+        "cid-removed": Fingerprint("token-gone"),
+    }
+    tests := []struct {
+        name    string
+        cid     string
+        want    string
+        wantErr bool
+    }{
+        {name: "persisted token", cid: "cid-b", want: "b"},
+        {name: "token removed from the pool", cid: "cid-removed", wantErr: true},
+        {name: "unknown conversation", cid: "cid-new", want: "a"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            p := testPool(t, Sticky)
+            p.SetBindingLookup(func(cid string) string { return persisted[cid] })
+            for i := 0; i < 2; i++ {
+                e, err := p.Pick(tt.cid, nil)
+                if tt.wantErr {
+                    if !errors.Is(err, ErrNoHealthyToken) {
+                        t.Fatalf("err = %v, want ErrNoHealthyToken", err)
+                    }
+                    return
+                }
+                if err != nil || e.Name != tt.want {
+                    t.Fatalf("pick %d = %q, %v; want %q", i, e.Name, err, tt.want)
+                }
+                p.Bind(tt.cid, e.Token)
+            }
+        })
+    }
+}
+
+func TestMask(t *testing.T) {
+    if got := Mask("short"); got != "****" {
+        t.Errorf("Mask(short) = %q", got)
+    }
+    if got := Mask("abcd-0123456789-wxyz"); got != "abcd…wxyz" {
+        t.Errorf("Mask = %q", got)
+    }
+    if Fingerprint("a") == Fingerprint("b") || len(Fingerprint("a")) != 16 {
+        t.Error("Fingerprint is not a 16-digit hash")
+    }
+}
+
+func health(p *Pool, name string) Health {
+    for _, h := range p.Health() {
+        if h.Name == name {
+            return h
+        }
+    }
+    return Health{}
+}
@@ -0,0 +1,246 @@
+package usage
+
+import (
+    "bufio"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Record is one request in the ledger.
+type Record struct {
+    Time         time.Time `json:"time"`
+    Client       string    `json:"client"`
+    Path         string    `json:"path"`
+    Model        string    `json:"model,omitempty"`
+    Stream       bool      `json:"stream,omitempty"`
+    InputTokens  int       `json:"input_tokens"`
+    OutputTokens int       `json:"output_tokens"`
+    LatencyMs    int64     `json:"latency_ms"`
+    Status       int       `json:"status"`
+    Outcome      string    `json:"outcome"`
+}
+
+// Outcome classifies an HTTP status for the ledger.
+func Outcome(status int) string {
+    switch {
+    case status == 429:
+        return "throttled"
+    case status == 499:
+        return "canceled"
+    case status >= 400:
+        return "error"
+    }
+    return "ok"
+}
+
+// Filter selects records; zero fields match everything. To is exclusive.
+type Filter struct {
+    Client string
+    From   time.Time
+    To     time.Time
+}
+
+func (f Filter) match(r Record) bool {
+    if f.Client != "" && r.Client != f.Client {
+        return false
+    }
+    if !f.From.IsZero() && r.Time.Before(f.From) {
+        return false
+    }
+    if !f.To.IsZero() && !r.Time.Before(f.To) {
+        return false
+    }
+    return true
+}
+
+// Group aggregates records for one day and model.
+type Group struct {
+    Day          string `json:"day"`
+    Model        string `json:"model"`
+    Requests     int    `json:"requests"`
+    Errors       int    `json:"errors"`
+    InputTokens  int    `json:"input_tokens"`
+    OutputTokens int    `json:"output_tokens"`
+    AvgLatencyMs int64  `json:"avg_latency_ms"`
+}
+
+// Report is a usage summary grouped by day and model.
+type Report struct {
+    Requests     int     `json:"requests"`
+    Errors       int     `json:"errors"`
+    InputTokens  int     `json:"input_tokens"`
+    OutputTokens int     `json:"output_tokens"`
+    Groups       []Group `json:"groups"`
+}
+
+// Ledger appends records to a JSON lines file and keeps the per-client totals
+// that quotas are checked against. An empty path keeps totals in memory only.
+type Ledger struct {
+    path   string
+    mu     sync.Mutex
+    file   *os.File
+    totals map[string]*totals
+}
+
+// Open opens (or creates) the ledger and rebuilds this month's totals from it.
+func Open(path string) (*Ledger, error) {
+    l := &Ledger{path: path, totals: map[string]*totals{}}
+    if path == "" {
+        return l, nil
+    }
+    monthStart := periodStart(time.Now().UTC(), "month")
+    err := Scan(path, Filter{From: monthStart}, func(r Record) {
+        l.totalsFor(r.Client).add(r)
+    })
+    if err != nil {
+        return nil, err
+    }
+    if dir := filepath.Dir(path); dir != "" {
+        if err := os.MkdirAll(dir, 0o755); err != nil {
+            return nil, fmt.Errorf("create usage dir: %w", err)
+        }
+    }
+    f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+    if err != nil {
+        return nil, fmt.Errorf("open usage ledger: %w", err)
+    }
+    l.file = f
+    return l, nil
+}
+
+// Path returns the ledger file, or "" when it is not persisted.
+func (l *Ledger) Path() string {
+    return l.path
+}
+
+// Add records r and counts it against the client's quotas.
+func (l *Ledger) Add(r Record) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.add(r)
+}
+
+// add records r; callers hold l.mu.
+func (l *Ledger) add(r Record) error {
+    l.totalsFor(r.Client).add(r)
+    if l.file == nil {
+        return nil
+    }
+    data, err := json.Marshal(r)
+    if err != nil {
+        return err
+    }
+    _, err = l.file.Write(append(data, '\n'))
+    return err
+}
+
+// Close closes the ledger file.
+func (l *Ledger) Close() error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.file == nil {
+        return nil
+    }
+    err := l.file.Close()
+    l.file = nil
+    return err
+}
+
+// Report summarizes the ledger file for f.
+func (l *Ledger) Report(f Filter) (Report, error) {
+    if l.path == "" {
+        return Report{Groups: []Group{}}, nil
+    }
+    return BuildReport(l.path, f)
+}
+
+// Scan calls fn for each record in the ledger file matching f. A missing file
+// has no records.
+func Scan(path string, f Filter, fn func(Record)) error {
+    file, err := os.Open(path)
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("open usage ledger: %w", err)
+    }
+    defer file.Close()
+
+    scanner := bufio.NewScanner(file)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    for scanner.Scan() {
+        var r Record
+        if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+            continue
+        }
+        if f.match(r) {
+            fn(r)
+        }
+    }
+    return scanner.Err()
+}
+
+// BuildReport reads the ledger file at path and groups matching records by day and model.
+func BuildReport(path string, f Filter) (Report, error) {
+    type acc struct {
+        Group
+        latency int64
+    }
+    groups := map[[2]string]*acc{}
+    var rep Report
+    err := Scan(path, f, func(r Record) {
+        k := [2]string{r.Time.UTC().Format(time.DateOnly), r.Model}
+        g := groups[k]
+        if g == nil {
+            g = &acc{Group: Group{Day: k[0], Model: k[1]}}
+            groups[k] = g
+        }
+        g.Requests++
+        g.InputTokens += r.InputTokens
+        g.OutputTokens += r.OutputTokens
+        g.latency += r.LatencyMs
+        rep.Requests++
+        rep.InputTokens += r.InputTokens
+        rep.OutputTokens += r.OutputTokens
+        if r.Outcome != "ok" {
+            g.Errors++
+            rep.Errors++
+        }
+    })
+    if err != nil {
+        return Report{}, err
+    }
+    rep.Groups = make([]Group, 0, len(groups))
+    for _, g := range groups {
+        g.AvgLatencyMs = g.latency / int64(g.Requests)
+        rep.Groups = append(rep.Groups, g.Group)
+    }
+    sort.Slice(rep.Groups, func(i, j int) bool {
+        if rep.Groups[i].Day != rep.Groups[j].Day {
+            return rep.Groups[i].Day < rep.Groups[j].Day
+        }
+        return rep.Groups[i].Model < rep.Groups[j].Model
+    })
+    return rep, nil
+}
+
+// ParseTime accepts a date (2006-01-02) or an RFC 3339 timestamp.
+func ParseTime(s string) (time.Time, error) {
+    if s == "" {
+        return time.Time{}, nil
+    }
+    if t, err := time.Parse(time.DateOnly, s); err == nil {
+        return t, nil
+    }
+    t, err := time.Parse(time.RFC3339, s)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("invalid time %q (want YYYY-MM-DD or RFC 3339)", s)
+    }
+    return t, nil
+}
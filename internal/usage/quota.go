@@ -0,0 +1,155 @@
+package usage
+
+import (
+    "fmt"
+    "time"
+)
+
+// Quota caps a client's usage per UTC day and calendar month. Zero fields are unlimited.
+type Quota struct {
+    DailyTokens     int
+    MonthlyTokens   int
+    DailyRequests   int
+    MonthlyRequests int
+}
+
+// Merge returns q with zero fields filled from def.
+func (q Quota) Merge(def Quota) Quota {
+    if q.DailyTokens == 0 {
+        q.DailyTokens = def.DailyTokens
+    }
+    if q.MonthlyTokens == 0 {
+        q.MonthlyTokens = def.MonthlyTokens
+    }
+    if q.DailyRequests == 0 {
+        q.DailyRequests = def.DailyRequests
+    }
+    if q.MonthlyRequests == 0 {
+        q.MonthlyRequests = def.MonthlyRequests
+    }
+    return q
+}
+
+// QuotaError reports an exhausted quota.
+type QuotaError struct {
+    Period  string // "day" or "month"
+    Unit    string // "tokens" or "requests"
+    Limit   int
+    ResetAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+    period := "monthly"
+    if e.Period == "day" {
+        period = "daily"
+    }
+    return fmt.Sprintf("%s quota of %d %s exhausted; resets at %s", period, e.Limit, e.Unit, e.ResetAt.Format(time.RFC3339))
+}
+
+type totals struct {
+    day           string
+    month         string
+    dayTokens     int
+    monthTokens   int
+    dayRequests   int
+    monthRequests int
+    // pending counts requests that passed Reserve and are not yet settled
+    pendingTokens   int
+    pendingRequests int
+}
+
+func (t *totals) roll(now time.Time) {
+    if d := now.Format(time.DateOnly); d != t.day {
+        t.day, t.dayTokens, t.dayRequests = d, 0, 0
+    }
+    if m := now.Format("2006-01"); m != t.month {
+        t.month, t.monthTokens, t.monthRequests = m, 0, 0
+    }
+}
+
+// add counts r unless it was throttled before reaching Sider.
+func (t *totals) add(r Record) {
+    if r.Outcome == "throttled" {
+        return
+    }
+    now := time.Now().UTC()
+    t.roll(now)
+    at := r.Time.UTC()
+    n := r.InputTokens + r.OutputTokens
+    if at.Format("2006-01") == t.month {
+        t.monthTokens += n
+        t.monthRequests++
+    }
+    if at.Format(time.DateOnly) == t.day {
+        t.dayTokens += n
+        t.dayRequests++
+    }
+}
+
+func (l *Ledger) totalsFor(client string) *totals {
+    t := l.totals[client]
+    if t == nil {
+        t = &totals{}
+        l.totals[client] = t
+    }
+    return t
+}
+
+// Reservation holds one request's share of a client's quota until it is settled.
+type Reservation struct {
+    client string
+    tokens int
+}
+
+// Reserve returns a *QuotaError when client has used up any part of q. Otherwise it
+// counts one request and tokens (the input estimate) against client until Settle, so
+// concurrent requests cannot all pass on the same remaining quota.
+func (l *Ledger) Reserve(client string, q Quota, tokens int) (*Reservation, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := time.Now().UTC()
+    t := l.totalsFor(client)
+    t.roll(now)
+    checks := []struct {
+        period, unit string
+        used, limit  int
+    }{
+        {"day", "requests", t.dayRequests + t.pendingRequests, q.DailyRequests},
+        {"day", "tokens", t.dayTokens + t.pendingTokens, q.DailyTokens},
+        {"month", "requests", t.monthRequests + t.pendingRequests, q.MonthlyRequests},
+        {"month", "tokens", t.monthTokens + t.pendingTokens, q.MonthlyTokens},
+    }
+    for _, c := range checks {
+        if c.limit > 0 && c.used >= c.limit {
+            return nil, &QuotaError{Period: c.period, Unit: c.unit, Limit: c.limit, ResetAt: periodEnd(now, c.period)}
+        }
+    }
+    t.pendingRequests++
+    t.pendingTokens += tokens
+    return &Reservation{client: client, tokens: tokens}, nil
+}
+
+// Settle releases res and records r, the actual usage, in its place.
+func (l *Ledger) Settle(res *Reservation, r Record) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    t := l.totalsFor(res.client)
+    t.pendingRequests--
+    t.pendingTokens -= res.tokens
+    return l.add(r)
+}
+
+func periodStart(t time.Time, period string) time.Time {
+    if period == "day" {
+        return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+    }
+    return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func periodEnd(t time.Time, period string) time.Time {
+    start := periodStart(t, period)
+    if period == "day" {
+        return start.AddDate(0, 0, 1)
+    }
+    return start.AddDate(0, 1, 0)
+}
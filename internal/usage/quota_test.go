@@ -0,0 +1,81 @@
+package usage
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestReserveHoldsQuotaForConcurrentRequests(t *testing.T) {
+    l, err := Open("")
+    if err != nil {
+        t.Fatal(err)
+    }
+    q := Quota{DailyRequests: 3}
+
+    var (
+        mu      sync.Mutex
+        granted []*Reservation
+        refused int
+        wg      sync.WaitGroup
+    )
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            res, err := l.Reserve("key:a", q, 0)
+            mu.Lock()
+            defer mu.Unlock()
+            var qe *QuotaError
+            switch {
+            case err == nil:
+                granted = append(granted, res)
+            case errors.As(err, &qe):
+                refused++
+            default:
+                t.Errorf("Reserve: %v", err)
+            }
+        }()
+    }
+    wg.Wait()
+    if len(granted) != 3 || refused != 7 {
+        t.Fatalf("granted %d, refused %d; want 3 and 7", len(granted), refused)
+    }
+
+    // settled requests keep counting; only throttled ones give their share back
+    l.Settle(granted[0], Record{Time: time.Now(), Client: "key:a", Status: 200, Outcome: Outcome(200)})
+    l.Settle(granted[1], Record{Time: time.Now(), Client: "key:a", Status: 429, Outcome: Outcome(429)})
+    if _, err := l.Reserve("key:a", q, 0); err != nil {
+        t.Fatalf("Reserve after a throttled request: %v", err)
+    }
+    if _, err := l.Reserve("key:a", q, 0); err == nil {
+        t.Fatal("Reserve over quota accepted")
+    }
+    if _, err := l.Reserve("key:b", q, 0); err != nil {
+        t.Fatalf("another client refused: %v", err)
+    }
+}
+
+func TestReserveCountsEstimatedTokens(t *testing.T) {
+    l, _ := Open("")
+    q := Quota{MonthlyTokens: 100}
+
+    first, err := l.Reserve("key:a", q, 60)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := l.Reserve("key:a", q, 60); err != nil {
+        t.Fatalf("second request refused under quota: %v", err)
+    }
+    var qe *QuotaError
+    if _, err := l.Reserve("key:a", q, 1); !errors.As(err, &qe) || qe.Unit != "tokens" || qe.Period != "month" {
+        t.Fatalf("err = %v, want the monthly token quota", err)
+    }
+
+    // the first request used far less than its estimate
+    l.Settle(first, Record{Time: time.Now(), Client: "key:a", InputTokens: 10, Status: 200, Outcome: Outcome(200)})
+    if _, err := l.Reserve("key:a", q, 1); err != nil {
+        t.Fatalf("Reserve after settling below the estimate: %v", err)
+    }
+}
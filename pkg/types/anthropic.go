@@ -163,6 +163,25 @@ type SiderMessageIDs struct {
     Assistant string `json:"assistant,omitempty"`
 }
 
+// AnthropicModel is one entry of GET /v1/models in the Anthropic format.
+type AnthropicModel struct {
+    Type          string             `json:"type"`
+    ID            string             `json:"id"`
+    DisplayName   string             `json:"display_name"`
+    CreatedAt     string             `json:"created_at"`
+    ContextWindow int                `json:"context_window,omitempty"`
+    Capabilities  *ModelCapabilities `json:"capabilities,omitempty"`
+    Default       bool               `json:"default,omitempty"`
+}
+
+// AnthropicModelList is the GET /v1/models response in the Anthropic format.
+type AnthropicModelList struct {
+    Data    []AnthropicModel `json:"data"`
+    HasMore bool             `json:"has_more"`
+    FirstID string           `json:"first_id,omitempty"`
+    LastID  string           `json:"last_id,omitempty"`
+}
+
 // HealthResponse is used by /health.
 type HealthResponse struct {
     Status    string `json:"status"`
@@ -170,4 +189,17 @@ type HealthResponse struct {
     Version   string `json:"version"`
     Timestamp string `json:"timestamp"`
     TechStack string `json:"tech_stack,omitempty"`
+    // Upstream is the circuit breaker view of Sider, omitted when the breaker is disabled.
+    Upstream *UpstreamHealth `json:"upstream,omitempty"`
+}
+
+// UpstreamHealth reports the upstream circuit breaker in /health.
+type UpstreamHealth struct {
+    Circuit   string  `json:"circuit"`
+    Requests  int     `json:"requests"`
+    ErrorRate float64 `json:"error_rate"`
+    SlowRate  float64 `json:"slow_rate"`
+    OpenedAt  string  `json:"opened_at,omitempty"`
+    RetryAt   string  `json:"retry_at,omitempty"`
+    LastError string  `json:"last_error,omitempty"`
 }
@@ -103,3 +103,30 @@ type OpenAIError struct {
     Type    string `json:"type"`
     Code    string `json:"code,omitempty"`
 }
+
+// OpenAIModel is one entry of GET /v1/models. DisplayName, ContextWindow and
+// Capabilities are extensions; OpenAI clients ignore them.
+type OpenAIModel struct {
+    ID            string             `json:"id"`
+    Object        string             `json:"object"`
+    Created       int64              `json:"created"`
+    OwnedBy       string             `json:"owned_by"`
+    DisplayName   string             `json:"display_name,omitempty"`
+    ContextWindow int                `json:"context_window,omitempty"`
+    Capabilities  *ModelCapabilities `json:"capabilities,omitempty"`
+    Default       bool               `json:"default,omitempty"`
+}
+
+// OpenAIModelList is the GET /v1/models response.
+type OpenAIModelList struct {
+    Object string        `json:"object"`
+    Data   []OpenAIModel `json:"data"`
+}
+
+// ModelCapabilities is the registry's capability view of a model.
+type ModelCapabilities struct {
+    Think  bool `json:"think"`
+    Search bool `json:"search"`
+    Vision bool `json:"vision"`
+    Images bool `json:"images"`
+}
@@ -0,0 +1,129 @@
+package types
+
+// OpenAI Responses API types (subset used by the proxy)
+// Reference: https://platform.openai.com/docs/api-reference/responses
+
+// ResponsesRequest is the body of POST /v1/responses.
+type ResponsesRequest struct {
+    Model              string              `json:"model"`
+    Input              any                 `json:"input"` // string or []ResponsesInputItem
+    Instructions       string              `json:"instructions,omitempty"`
+    PreviousResponseID string              `json:"previous_response_id,omitempty"`
+    Stream             bool                `json:"stream,omitempty"`
+    MaxOutputTokens    *int                `json:"max_output_tokens,omitempty"`
+    Temperature        *float64            `json:"temperature,omitempty"`
+    TopP               *float64            `json:"top_p,omitempty"`
+    Tools              []ResponsesTool     `json:"tools,omitempty"`
+    Reasoning          *ResponsesReasoning `json:"reasoning,omitempty"`
+    Metadata           map[string]string   `json:"metadata,omitempty"`
+}
+
+// ResponsesInputItem is one input item: a message, or the output of a function call.
+type ResponsesInputItem struct {
+    Type    string `json:"type,omitempty"` // "message" when empty
+    Role    string `json:"role,omitempty"`
+    Content any    `json:"content,omitempty"` // string or []ResponsesInputContent
+    // function_call_output
+    CallID string `json:"call_id,omitempty"`
+    Output string `json:"output,omitempty"`
+}
+
+// ResponsesInputContent is one content part of an input message.
+type ResponsesInputContent struct {
+    Type     string `json:"type"` // input_text, output_text, input_image
+    Text     string `json:"text,omitempty"`
+    ImageURL string `json:"image_url,omitempty"`
+}
+
+// ResponsesTool is a hosted tool (web_search, image_generation) or a function.
+type ResponsesTool struct {
+    Type        string         `json:"type"`
+    Name        string         `json:"name,omitempty"`
+    Description string         `json:"description,omitempty"`
+    Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ResponsesReasoning configures reasoning; effort "none" or "minimal" turns it off.
+type ResponsesReasoning struct {
+    Effort  string `json:"effort,omitempty"`
+    Summary string `json:"summary,omitempty"`
+}
+
+// ResponsesResponse is a response object, returned whole or inside stream events.
+type ResponsesResponse struct {
+    ID                 string                `json:"id"`
+    Object             string                `json:"object"`
+    CreatedAt          int64                 `json:"created_at"`
+    Status             string                `json:"status"`
+    Model              string                `json:"model"`
+    Instructions       *string               `json:"instructions"`
+    PreviousResponseID *string               `json:"previous_response_id"`
+    Output             []ResponsesOutputItem `json:"output"`
+    Error              *ResponsesError       `json:"error"`
+    Usage              *ResponsesUsage       `json:"usage,omitempty"`
+    Metadata           map[string]string     `json:"metadata,omitempty"`
+}
+
+// ResponsesOutputItem is a message, reasoning or web_search_call output item.
+type ResponsesOutputItem struct {
+    ID     string `json:"id"`
+    Type   string `json:"type"`
+    Status string `json:"status,omitempty"`
+    // message
+    Role    string                `json:"role,omitempty"`
+    Content []ResponsesOutputText `json:"content,omitempty"`
+    // reasoning
+    Summary []ResponsesSummaryText `json:"summary,omitempty"`
+    // web_search_call
+    Action *ResponsesSearchAction `json:"action,omitempty"`
+}
+
+// ResponsesOutputText is an output_text content part.
+type ResponsesOutputText struct {
+    Type        string `json:"type"`
+    Text        string `json:"text"`
+    Annotations []any  `json:"annotations"`
+}
+
+// ResponsesSummaryText is a summary_text part of a reasoning item.
+type ResponsesSummaryText struct {
+    Type string `json:"type"`
+    Text string `json:"text"`
+}
+
+// ResponsesSearchAction describes the search a web_search_call ran.
+type ResponsesSearchAction struct {
+    Type    string                  `json:"type"`
+    Query   string                  `json:"query,omitempty"`
+    Sources []ResponsesSearchSource `json:"sources,omitempty"`
+}
+
+// ResponsesSearchSource is one page a search returned.
+type ResponsesSearchSource struct {
+    Type  string `json:"type"`
+    URL   string `json:"url"`
+    Title string `json:"title,omitempty"`
+}
+
+// ResponsesUsage reports token counts.
+type ResponsesUsage struct {
+    InputTokens         int                       `json:"input_tokens"`
+    InputTokensDetails  ResponsesInputTokensInfo  `json:"input_tokens_details"`
+    OutputTokens        int                       `json:"output_tokens"`
+    OutputTokensDetails ResponsesOutputTokensInfo `json:"output_tokens_details"`
+    TotalTokens         int                       `json:"total_tokens"`
+}
+
+type ResponsesInputTokensInfo struct {
+    CachedTokens int `json:"cached_tokens"`
+}
+
+type ResponsesOutputTokensInfo struct {
+    ReasoningTokens int `json:"reasoning_tokens"`
+}
+
+// ResponsesError is the error of a failed response.
+type ResponsesError struct {
+    Code    string `json:"code"`
+    Message string `json:"message"`
+}
@@ -45,6 +45,8 @@ type SiderRequest struct {
     ExtraInfo        *SiderExtraInfo          `json:"extra_info,omitempty"`
     OutputLanguage   string                   `json:"output_language,omitempty"`
     ThinkMode        *SiderThinkMode          `json:"think_mode,omitempty"`
+    // Warnings lists the adjustments made to fit the model; not sent upstream.
+    Warnings         []string                 `json:"-"`
 }
 
 type SiderPromptTemplate struct {
@@ -115,6 +117,8 @@ type SiderParsedResponse struct {
     Model          string
     ConversationID string
     MessageIDs     *SiderMessageIDs
+    // Attempts is the number of upstream requests it took to get this response.
+    Attempts       int
 }
 
 type SiderToolResult struct {
@@ -124,3 +128,19 @@ type SiderToolResult struct {
     Status   string `json:"status"`
     Error    string `json:"error,omitempty"`
 }
+
+// Conversation history payloads
+
+type SiderConversationMessage struct {
+    ID              string                     `json:"id"`
+    ParentMessageID string                     `json:"parent_message_id"`
+    Role            string                     `json:"role"`
+    Model           string                     `json:"model,omitempty"`
+    MultiContent    []SiderConversationContent `json:"multi_content"`
+}
+
+type SiderConversationContent struct {
+    Type          string `json:"type"`
+    Text          string `json:"text"`
+    UserInputText string `json:"user_input_text,omitempty"`
+}
@@ -419,7 +419,51 @@ async function sendMessage() {
   }
 }
 
-// 页面加载完成后聚焦输入框
+// 从 /v1/models 加载模型列表并选中默认模型；加载失败时保留页面自带的备选项
+async function loadModels() {
+  try {
+    const response = await fetch('/v1/models', {
+      headers: { 'Authorization': 'Bearer ' + API_TOKEN }
+    });
+    if (!response.ok) {
+      throw new Error('HTTP ' + response.status);
+    }
+    const body = await response.json();
+    const list = body.data || [];
+    if (list.length === 0) {
+      throw new Error('列表为空');
+    }
+    modelSelect.innerHTML = '';
+    for (const model of list) {
+      const option = document.createElement('option');
+      option.value = model.id;
+      option.textContent = model.display_name || model.id;
+      const caps = model.capabilities || {};
+      option.dataset.think = caps.think ? '1' : '';
+      option.dataset.search = caps.search ? '1' : '';
+      option.selected = !!model.default;
+      modelSelect.appendChild(option);
+    }
+  } catch (error) {
+    showStatus('加载模型列表失败: ' + error.message, 'error');
+  }
+  applyCapabilities();
+}
+
+// 根据所选模型的能力启用或禁用思考与搜索选项
+function applyCapabilities() {
+  const option = modelSelect.selectedOptions[0];
+  if (!option) return;
+  thinkingCheckbox.disabled = !option.dataset.think;
+  searchCheckbox.disabled = !option.dataset.search;
+  if (thinkingCheckbox.disabled) thinkingCheckbox.checked = false;
+  if (searchCheckbox.disabled) searchCheckbox.checked = false;
+}
+
+modelSelect.addEventListener('change', applyCapabilities);
+
+// 页面加载完成后加载模型并聚焦输入框
 window.addEventListener('load', () => {
+  loadModels();
   inputEl.focus();
 });